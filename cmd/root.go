@@ -1,10 +1,17 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -12,15 +19,205 @@ import (
 )
 
 var (
-    maxFiles int
-    maxDirs  int
-    maxLevel int
+	maxFiles int
+	maxDirs  int
+	maxLevel int
+
+	extSummary      bool
+	preserveExtCase bool
+
+	splitOutput string
+	splitLevel  int
+
+	pathTo string
+
+	showEntropy bool
+
+	execCommand  string
+	execParallel int
+	noTree       bool
+
+	sortBy string
+
+	pathsInTree bool
+	skipOver    int
+
+	format      string
+	perTopLevel bool
+
+	sampleFiles int
+	sampleSeed  int64
+
+	rounded     bool
+	readmeCheck bool
+
+	oneFilesystem bool
+
+	showLanguages bool
+	languagesBy   string
+
+	noRoot   bool
+	noReport bool
+
+	useCache bool
+	cacheDir string
+
+	showFilteredCounts bool
+	width              int
+
+	excludePatterns  []string
+	showOnlyExcluded bool
+
+	expectFile string
+
+	fullPath    bool
+	stripPrefix string
+
+	urlBase string
+	urlDirs bool
+
+	collapseNumbered bool
+	expandNumbered   bool
+
+	totals     bool
+	totalsOmit []string
+
+	overview bool
+
+	hideSizeOver string
+
+	writeSignatures string
+	changedSince    string
+
+	hyperlinks bool
+
+	byDate      bool
+	dateBuckets string
+
+	numbered          bool
+	numberEntriesOnly bool
+
+	anchor string
+
+	diskUsage     bool
+	diskUsageSort bool
+
+	skipSpecial bool
+
+	indentWidth int
+
+	foldAt int
+
+	svgFont     string
+	svgFontSize int
+
+	stopAt []string
+
+	heatmap bool
+
+	sinceCommit string
+
+	deterministic bool
+
+	previewLines   int
+	previewMaxSize string
+
+	balance bool
+
+	noEnvIgnore bool
+
+	sizeBars bool
+
+	maxComponents int
+
+	dumpInternal bool
+
+	expandArchives bool
+
+	nameStats bool
+	nameLimit int
+
+	timeout time.Duration
+
+	mergeRoots string
+
+	lintNames  string
+	failOnLint bool
+
+	sections             bool
+	sectionChars         int
+	sectionCaseSensitive bool
+
+	annotateJSON bool
+
+	signatureDepth int
+
+	oneline bool
+
+	packages         bool
+	expandPackages   bool
+	packageManifests []string
+
+	blame bool
+
+	emitIgnorePath string
+
+	labelTemplate     string
+	fileLabelTemplate string
+
+	similar float64
+
+	siteDir string
+
+	gitExtChurn string
+
+	rootSlash string
+
+	grepPattern string
+	grepMaxSize string
+	grepCount   bool
+
+	shape bool
+
+	autofit bool
+
+	useGitignore bool
+
+	concurrency int
+
+	includePatterns []string
+
+	followSymlinks bool
+
+	colorMode string
+
+	charset string
+
+	reverse   bool
+	dirsFirst bool
+
+	showHidden bool
+
+	dirsOnly bool
 )
 
+// singleRootFlags lists the flags that post-process or report on one walked
+// tree and are therefore rejected when several paths are given.
+var singleRootFlags = []string{
+	"per-top-level", "split-output", "exec", "write-signatures", "changed-since",
+	"since-commit", "expect", "anchor", "path-to", "dump-internal", "oneline",
+	"blame", "site", "git-ext-churn",
+}
+
+// ignoreEnv names the environment variable holding default exclude globs.
+const ignoreEnv = "TREE_PRO_IGNORE"
+
 var rootCmd = &cobra.Command{
-	Use:   "tree-pro [path]",
+	Use:   "tree-pro [path...]",
 	Short: "Print a concise, colored directory tree",
-	Args:  cobra.MaximumNArgs(1),
+	Args:  cobra.ArbitraryArgs,
+	// Execute prints the error itself.
+	SilenceErrors: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if maxFiles < 0 {
 			return fmt.Errorf("--files must be >= 0")
@@ -31,6 +228,128 @@ var rootCmd = &cobra.Command{
 		if maxLevel < 0 {
 			return fmt.Errorf("--level must be >= 0")
 		}
+		if splitLevel < 1 {
+			return fmt.Errorf("--split-level must be >= 1")
+		}
+		switch charset {
+		case internal.CharsetUnicode, internal.CharsetASCII, internal.CharsetRounded:
+		default:
+			return fmt.Errorf("--charset must be one of: unicode, ascii, rounded")
+		}
+		if rounded && cmd.Flags().Changed("charset") && charset != internal.CharsetRounded {
+			return fmt.Errorf("--rounded cannot be combined with --charset %s", charset)
+		}
+		switch colorMode {
+		case colorAuto, colorAlways, colorNever:
+		default:
+			return fmt.Errorf("--color must be one of: auto, always, never")
+		}
+		if dirsOnly && format == internal.FormatPaths {
+			return fmt.Errorf("--dirs-only cannot be combined with --format paths-json")
+		}
+		if concurrency < 0 {
+			return fmt.Errorf("--concurrency must be >= 0")
+		}
+		if execParallel < 1 {
+			return fmt.Errorf("--exec-parallel must be >= 1")
+		}
+		switch format {
+		case internal.FormatTree, internal.FormatUL, internal.FormatD3, internal.FormatURLs, internal.FormatMermaid, internal.FormatIndent, internal.FormatSVG, internal.FormatHTML, internal.FormatPaths, internal.FormatJSON:
+		default:
+			return fmt.Errorf("--format must be one of: tree, ul, d3, urls, mermaid, indent, svg, html, paths-json, json")
+		}
+		if mergeRoots != "" {
+			for _, name := range []string{"since-commit", "max-components"} {
+				if cmd.Flags().Changed(name) {
+					return fmt.Errorf("--%s cannot be combined with --merge-roots", name)
+				}
+			}
+		} else if len(args) > 1 {
+			if format != internal.FormatHTML {
+				return fmt.Errorf("several paths require --format html or --merge-roots")
+			}
+			for _, name := range singleRootFlags {
+				if cmd.Flags().Changed(name) {
+					return fmt.Errorf("--%s works on a single path only", name)
+				}
+			}
+		}
+		if languagesBy != "" && languagesBy != "count" && languagesBy != "bytes" {
+			return fmt.Errorf("--lang-by must be one of: count, bytes")
+		}
+		if diskUsageSort && !diskUsage && !oneline {
+			return fmt.Errorf("--du-sort requires --du or --oneline")
+		}
+		if sizeBars && !diskUsage {
+			return fmt.Errorf("--bars requires --du")
+		}
+		if indentWidth < 1 {
+			return fmt.Errorf("--indent-width must be >= 1")
+		}
+		if svgFontSize < 1 {
+			return fmt.Errorf("--svg-font-size must be >= 1")
+		}
+		if nameLimit < 0 {
+			return fmt.Errorf("--name-limit must be >= 0")
+		}
+		if maxComponents < 0 {
+			return fmt.Errorf("--max-components must be >= 0")
+		}
+		if foldAt < 0 {
+			return fmt.Errorf("--fold-at must be >= 0")
+		}
+		if width < 0 {
+			return fmt.Errorf("--width must be >= 0")
+		}
+		for _, category := range totalsOmit {
+			if !slices.Contains(internal.TotalsCategories, category) {
+				return fmt.Errorf("--totals-omit must name one of: %s", strings.Join(internal.TotalsCategories, ", "))
+			}
+		}
+		if sampleFiles < 0 {
+			return fmt.Errorf("--sample must be >= 0")
+		}
+		if skipOver < 0 {
+			return fmt.Errorf("--skip-over must be >= 0")
+		}
+		switch sortBy {
+		case internal.SortName, internal.SortSize, internal.SortMtime, internal.SortNone:
+		default:
+			return fmt.Errorf("--sort must be one of: name, size, mtime, none")
+		}
+		if failOnLint && lintNames == "" {
+			return fmt.Errorf("--fail-on-lint requires --lint-names")
+		}
+		var lintPattern *regexp.Regexp
+		if lintNames != "" {
+			var err error
+			if lintPattern, err = regexp.Compile(lintNames); err != nil {
+				return fmt.Errorf("--lint-names: %w", err)
+			}
+		}
+		if expandPackages && !packages {
+			return fmt.Errorf("--expand-packages requires --packages")
+		}
+		if similar <= 0 || similar > 1 {
+			return fmt.Errorf("--similar must be greater than 0 and at most 1")
+		}
+		if rootSlash != rootSlashAuto && rootSlash != rootSlashAlways && rootSlash != rootSlashNever {
+			return fmt.Errorf("--root-slash must be one of: auto, always, never")
+		}
+		if signatureDepth < 0 {
+			return fmt.Errorf("--signature-depth must be >= 0")
+		}
+		if deterministic && sortBy == internal.SortNone {
+			return fmt.Errorf("--deterministic cannot be combined with --sort none")
+		}
+
+		// The flags are valid, so later failures, such as missing --expect
+		// entries or lint violations, are not usage errors.
+		cmd.SilenceUsage = true
+
+		if emitIgnorePath != "" {
+			return emitIgnore(cmd, emitIgnorePath)
+		}
 
 		target := "."
 		if len(args) > 0 {
@@ -38,24 +357,354 @@ var rootCmd = &cobra.Command{
 		}
 		cleaned := filepath.Clean(target)
 
+		var hideSizeOverBytes int64
+		if hideSizeOver != "" {
+			var err error
+			if hideSizeOverBytes, err = internal.ParseSize(hideSizeOver); err != nil {
+				return fmt.Errorf("--hide-size-over: %w", err)
+			}
+		}
+		if previewLines < 0 {
+			return fmt.Errorf("--preview must be >= 0")
+		}
+		previewMaxBytes, err := internal.ParseSize(previewMaxSize)
+		if err != nil {
+			return fmt.Errorf("--preview-max-size: %w", err)
+		}
+		if grepCount && grepPattern == "" {
+			return fmt.Errorf("--grep-count requires --grep")
+		}
+		var contentPattern *regexp.Regexp
+		if grepPattern != "" {
+			if contentPattern, err = regexp.Compile(grepPattern); err != nil {
+				return fmt.Errorf("--grep: %w", err)
+			}
+		}
+		grepMaxBytes, err := internal.ParseSize(grepMaxSize)
+		if err != nil {
+			return fmt.Errorf("--grep-max-size: %w", err)
+		}
+
 		walkerOpts := internal.Options{
-			MaxFiles: maxFiles,
-			MaxLevel: maxLevel,
+			MaxFiles:         maxFiles,
+			MaxLevel:         maxLevel,
+			SortBy:           sortBy,
+			Reverse:          reverse,
+			ShowHidden:       showHidden,
+			DirsOnly:         dirsOnly,
+			SkipOver:         skipOver,
+			SampleFiles:      sampleFiles,
+			Seed:             sampleSeed,
+			OneFilesystem:    oneFilesystem,
+			IncludePatterns:  includePatterns,
+			ExcludePatterns:  withEnvIgnore(excludePatterns, !noEnvIgnore),
+			ShowOnlyExcluded: showOnlyExcluded,
+			HideSizeOver:     hideSizeOverBytes,
+			SkipSpecial:      skipSpecial,
+			StopAt:           stopAt,
+			PreviewLines:     previewLines,
+			PreviewMaxSize:   previewMaxBytes,
+			ExpandArchives:   expandArchives,
+			SignatureDepth:   signatureDepth,
+			ContentPattern:   contentPattern,
+			ContentMaxSize:   grepMaxBytes,
+			UseGitignore:     useGitignore,
+			FollowSymlinks:   followSymlinks,
+			Concurrency:      concurrency,
+		}
+		if packages {
+			walkerOpts.PackageManifests = packageManifests
+		}
+		if pathTo != "" || sinceCommit != "" || format == internal.FormatPaths || failOnLint || execCommand != "" {
+			// Keep every file the walker sees so truncation cannot hide a
+			// --path-to match, a changed file, an entry of the paths-json
+			// list, a naming violation or a file to --exec on.
+			walkerOpts.MaxFiles = 0
+		}
+		if pathTo != "" || expectFile != "" || sinceCommit != "" || lintPattern != nil || execCommand != "" {
+			// These read the files of the tree, so -D keeps them in the walk
+			// and only the printer leaves them out, through HideFiles.
+			walkerOpts.DirsOnly = false
 		}
 
-		dir, err := internal.Walk(cleaned, walkerOpts)
-		if err != nil {
+		ctx := cmd.Context()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		dir, err := walk(ctx, cleaned, walkerOpts)
+		timedOut := errors.Is(err, context.DeadlineExceeded) && dir != nil
+		if err != nil && !timedOut {
 			return err
 		}
+		label := formatRootLabel(target, rootSlash)
+		if mergeRoots != "" {
+			roots := []internal.MergeRoot{{Path: cleaned, Dir: dir}}
+			for _, path := range args[min(len(args), 1):] {
+				other, err := walk(ctx, filepath.Clean(path), walkerOpts)
+				if errors.Is(err, context.DeadlineExceeded) && other != nil {
+					timedOut = true
+				} else if err != nil {
+					return err
+				}
+				roots = append(roots, internal.MergeRoot{Path: filepath.Clean(path), Dir: other})
+			}
+			dir = internal.MergeRoots(mergeRoots, roots)
+			label = mergeRoots
+		}
+		if contentPattern != nil {
+			dir = internal.PruneEmptyDirs(dir)
+		}
+		if dumpInternal {
+			return internal.DumpDirectory(cmd.OutOrStdout(), dir)
+		}
+
+		printerOpts := internal.PrinterOptions{
+			Writer:             cmd.OutOrStdout(),
+			MaxDirs:            maxDirs,
+			UseColor:           useColor(cmd.OutOrStdout()),
+			ExtSummary:         extSummary,
+			PreserveExtCase:    preserveExtCase,
+			PathTo:             pathTo,
+			ShowEntropy:        showEntropy,
+			PathsInTree:        pathsInTree,
+			Format:             format,
+			ReadmeCheck:        readmeCheck,
+			ShowLanguages:      showLanguages,
+			LanguagesBySize:    languagesBy == "bytes" || languagesBy == "" && diskUsage,
+			NoRoot:             noRoot,
+			NoReport:           noReport,
+			ShowFilteredCounts: showFilteredCounts,
+			Width:              width,
+			ExcludedReport:     showOnlyExcluded,
+			FullPath:           fullPath,
+			StripPrefix:        stripPrefix,
+			URLBase:            urlBase,
+			URLDirs:            urlDirs,
+			CollapseNumbered:   collapseNumbered,
+			ExpandNumbered:     expandNumbered,
+			Totals:             totals,
+			TotalsOmit:         totalsOmit,
+			Overview:           overview,
+			Hyperlinks:         hyperlinks,
+			NumberLines:        numbered,
+			NumberEntriesOnly:  numberEntriesOnly,
+			Anchor:             anchor,
+			DiskUsage:          diskUsage,
+			DiskUsageSort:      diskUsageSort,
+			DirsFirst:          dirsFirst,
+			SortBy:             sortBy,
+			Reverse:            reverse,
+			IndentWidth:        indentWidth,
+			FoldAt:             foldAt,
+			Packages:           packages,
+			ExpandPackages:     expandPackages,
+			Similarity:         similar,
+			MatchCounts:        grepCount,
+			SVGFont:            svgFont,
+			SVGFontSize:        svgFontSize,
+			Heatmap:            heatmap,
+			Balance:            balance,
+			SizeBars:           sizeBars,
+			NameStats:          nameStats,
+			NameLimit:          nameLimit,
+			LintNames:          lintPattern,
+			HideFiles:          dirsOnly,
+		}
+		if timedOut {
+			printerOpts.WalkTimeout = timeout
+		}
+		if maxComponents > 0 {
+			rootComponents := pathComponents(cleaned)
+			if maxComponents <= rootComponents {
+				return fmt.Errorf("--max-components %d leaves nothing below %s, which already has %d components", maxComponents, cleaned, rootComponents)
+			}
+			printerOpts.MaxDepth = maxComponents - rootComponents
+		}
+		if byDate {
+			if format != internal.FormatTree && format != internal.FormatIndent {
+				return fmt.Errorf("--by-date requires --format tree or indent")
+			}
+			now := time.Now()
+			printerOpts.DateBuckets = internal.DefaultDateBuckets(now)
+			if dateBuckets != "" {
+				buckets, err := internal.ParseDateBuckets(dateBuckets, now)
+				if err != nil {
+					return fmt.Errorf("--date-buckets: %w", err)
+				}
+				printerOpts.DateBuckets = buckets
+			}
+		}
+		if sections {
+			if format != internal.FormatTree && format != internal.FormatIndent {
+				return fmt.Errorf("--sections requires --format tree or indent")
+			}
+			if sectionChars < 1 {
+				return fmt.Errorf("--section-chars must be >= 1")
+			}
+			printerOpts.SectionChars = sectionChars
+			printerOpts.SectionCaseSensitive = sectionCaseSensitive
+		}
+		if autofit && format != internal.FormatTree {
+			return fmt.Errorf("--autofit requires --format tree")
+		}
+		if shape {
+			if format != internal.FormatTree {
+				return fmt.Errorf("--shape requires --format tree")
+			}
+			printerOpts.Shape = true
+		}
+		if annotateJSON {
+			if format != internal.FormatTree {
+				return fmt.Errorf("--annotate-json requires --format tree")
+			}
+			if width > 0 {
+				return fmt.Errorf("--annotate-json cannot be combined with --width, which would cut the JSON short")
+			}
+			printerOpts.AnnotateJSON = true
+		}
+		if labelTemplate != "" {
+			if printerOpts.LabelTemplate, err = internal.ParseLabelTemplate("label", labelTemplate); err != nil {
+				return fmt.Errorf("--label-template: %w", err)
+			}
+		}
+		if fileLabelTemplate != "" {
+			if printerOpts.FileLabelTemplate, err = internal.ParseLabelTemplate("file-label", fileLabelTemplate); err != nil {
+				return fmt.Errorf("--file-label-template: %w", err)
+			}
+		}
+		printerOpts.Charset = charset
+		if rounded {
+			printerOpts.Charset = internal.CharsetRounded
+		}
+		if len(args) > 1 && mergeRoots == "" {
+			trees := []internal.HTMLTree{{Label: label, Dir: dir}}
+			for _, path := range args[1:] {
+				other, err := walk(ctx, filepath.Clean(path), walkerOpts)
+				if errors.Is(err, context.DeadlineExceeded) && other != nil {
+					printerOpts.WalkTimeout = timeout
+				} else if err != nil {
+					return err
+				}
+				trees = append(trees, internal.HTMLTree{Label: formatRootLabel(path, rootSlash), Dir: other})
+			}
+			return internal.PrintHTMLPage(trees, printerOpts)
+		}
+		if writeSignatures != "" {
+			if err := internal.WriteSignaturesFile(writeSignatures, dir); err != nil {
+				return err
+			}
+		}
+		if changedSince != "" {
+			baseline, err := internal.ReadSignaturesFile(changedSince)
+			if err != nil {
+				return err
+			}
+			dir = internal.PruneUnchanged(dir, baseline)
+			if dir == nil {
+				fmt.Fprintln(cmd.OutOrStdout(), "no directories changed")
+				return nil
+			}
+		}
+
+		if blame {
+			authors, err := internal.GitFileAuthors(cleaned)
+			if err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "--blame: %v; showing the tree without authors\n", err)
+			} else {
+				printerOpts.Authors = authors
+			}
+		}
+		if gitExtChurn != "" {
+			changed, err := internal.GitChangedFiles(cleaned, gitExtChurn)
+			if err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "--git-ext-churn: %v; showing the tree without it\n", err)
+			} else {
+				printerOpts.ExtChurn = internal.SummarizeChangedExtensions(changed)
+				printerOpts.ExtChurnRef = gitExtChurn
+			}
+		}
+		if sinceCommit != "" {
+			changed, err := internal.GitChangedFiles(cleaned, sinceCommit)
+			if err != nil {
+				return fmt.Errorf("--since-commit: %w", err)
+			}
+			dir = internal.PruneToPaths(dir, changed)
+			if dir == nil {
+				fmt.Fprintf(cmd.OutOrStdout(), "no files changed since %s\n", sinceCommit)
+				return nil
+			}
+		}
 
-		label := formatRootLabel(target)
-        printerOpts := internal.PrinterOptions{
-            Writer:   cmd.OutOrStdout(),
-            MaxDirs:  maxDirs,
-            UseColor: true,
-        }
-        return internal.PrintTree(label, dir, printerOpts)
-    },
+		var missing []string
+		if expectFile != "" {
+			paths, err := internal.ReadPathListFile(expectFile)
+			if err != nil {
+				return err
+			}
+			missing = internal.MarkExpected(dir, internal.BuildFromPaths(dir.Name, paths))
+		}
+		if pathTo != "" && internal.CountPathMatches(dir, pathTo) == 0 {
+			return fmt.Errorf("no entry matching %q found", pathTo)
+		}
+		if perTopLevel {
+			if format == internal.FormatJSON {
+				return internal.PrintTopLevelJSON(cmd.OutOrStdout(), internal.TopLevelStats(dir))
+			}
+			return internal.PrintTopLevel(cmd.OutOrStdout(), internal.TopLevelStats(dir))
+		}
+		if oneline {
+			return internal.PrintOneline(cmd.OutOrStdout(), internal.TopLevelStats(dir), diskUsageSort)
+		}
+		if splitOutput != "" {
+			results, err := internal.WriteSplit(splitOutput, dir, splitLevel, printerOpts)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "wrote %d subtrees to %s\n", len(results), splitOutput)
+			return nil
+		}
+		if siteDir != "" {
+			pages, err := internal.WriteSite(siteDir, label, dir, printerOpts)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "wrote %d pages to %s\n", pages, siteDir)
+			return nil
+		}
+		if autofit {
+			if height := terminalHeight(); height > 0 {
+				// Leave a line for the shell prompt.
+				depth, err := internal.FitDepth(label, dir, printerOpts, height-1)
+				if err != nil {
+					return err
+				}
+				printerOpts.MaxDepth = depth
+			}
+		}
+		if !noTree {
+			if err := internal.PrintTree(label, dir, printerOpts); err != nil {
+				return err
+			}
+		}
+		if execCommand != "" {
+			// Run on the final tree so every filter applied after the walk,
+			// such as --since-commit, --changed-since or --path-to, counts.
+			if err := runExec(cmd, internal.ExecPaths(dir, pathTo), printerOpts.UseColor); err != nil {
+				return err
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("%d expected paths missing", len(missing))
+		}
+		if failOnLint {
+			if count := internal.CountNameViolations(dir, lintPattern); count > 0 {
+				return fmt.Errorf("%d file names do not match %s", count, lintNames)
+			}
+		}
+		return nil
+	},
 }
 
 // Execute runs the CLI.
@@ -67,23 +716,262 @@ func Execute() {
 }
 
 func init() {
-    rootCmd.Flags().IntVarP(&maxFiles, "files", "f", 5, "maximum files to display per directory (0 for unlimited)")
-    rootCmd.Flags().IntVarP(&maxDirs, "dirs", "d", 1, "maximum identical directories to expand per group (0 for unlimited)")
-    rootCmd.Flags().IntVarP(&maxLevel, "level", "L", 0, "maximum recursion depth (0 for unlimited)")
+	rootCmd.Flags().IntVarP(&maxFiles, "files", "f", 5, "maximum files to display per directory (0 for unlimited)")
+	rootCmd.Flags().IntVarP(&maxDirs, "dirs", "d", 1, "maximum identical directories to expand per group (0 for unlimited)")
+	rootCmd.Flags().IntVarP(&maxLevel, "level", "L", 0, "maximum recursion depth (0 for unlimited)")
+	rootCmd.Flags().BoolVar(&extSummary, "ext-summary", false, "print a per-extension file count after the tree")
+	rootCmd.Flags().BoolVar(&preserveExtCase, "preserve-ext-case", false, "show the most common original extension casing in the summary")
+	rootCmd.Flags().StringVar(&splitOutput, "split-output", "", "write each subtree to its own file in this directory, plus an index")
+	rootCmd.Flags().IntVar(&splitLevel, "split-level", 1, "depth below the root at which --split-output splits subtrees")
+	rootCmd.Flags().StringVar(&pathTo, "path-to", "", "show only the paths leading to entries matching this name or glob")
+	rootCmd.Flags().BoolVar(&showEntropy, "entropy", false, "append each directory's file-type diversity (Shannon entropy in bits)")
+	rootCmd.Flags().StringVar(&execCommand, "exec", "", "run a command for each file, replacing {} with its path")
+	rootCmd.Flags().IntVar(&execParallel, "exec-parallel", 1, "number of --exec commands to run concurrently")
+	rootCmd.Flags().BoolVar(&noTree, "no-tree", false, "do not print the tree (useful with --exec)")
+	rootCmd.Flags().StringVar(&sortBy, "sort", internal.SortName, "entry order: name, size (largest first), mtime (newest first), or none to keep the filesystem's readdir order")
+	rootCmd.Flags().BoolVar(&pathsInTree, "paths-in-tree", false, "show each file's path relative to the root instead of its name")
+	rootCmd.Flags().IntVar(&skipOver, "skip-over", 0, "do not expand directories with more than this many entries (0 for no limit)")
+	rootCmd.Flags().StringVar(&format, "format", internal.FormatTree, "output format: tree, ul (nested HTML list), d3 (JSON nodes and links), urls, mermaid, indent (plain spaces), svg, html (tabbed page, one tab per path), paths-json (sorted file list) or json (nested directory objects)")
+	rootCmd.Flags().BoolVar(&perTopLevel, "per-top-level", false, "print recursive totals for each top-level directory instead of the tree")
+	rootCmd.Flags().IntVar(&sampleFiles, "sample", 0, "show a reproducible random sample of this many files per directory instead of the first --files")
+	rootCmd.Flags().Int64Var(&sampleSeed, "seed", 0, "random seed for --sample")
+	rootCmd.Flags().BoolVar(&rounded, "rounded", false, "draw the last branch of each directory with a rounded corner (╰──)")
+	rootCmd.Flags().BoolVar(&readmeCheck, "readme-check", false, "mark directories with or without a README and list those missing one")
+	rootCmd.Flags().BoolVar(&oneFilesystem, "one-filesystem", false, "do not descend into directories on other filesystems")
+	rootCmd.Flags().BoolVar(&showLanguages, "lang", false, "annotate files with their language and print a language breakdown, weighted by bytes with --size")
+	rootCmd.Flags().StringVar(&languagesBy, "lang-by", "", "weight the --lang breakdown by count or bytes, overriding --size (default: bytes with --size, count otherwise)")
+	rootCmd.Flags().BoolVar(&noRoot, "no-root", false, "omit the root line and print its entries at the leftmost column")
+	rootCmd.Flags().BoolVar(&noReport, "noreport", false, "omit the directory and file count line")
+	rootCmd.Flags().BoolVar(&useCache, "cache", false, "reuse a cached walk while no directory's mtime has changed")
+	rootCmd.Flags().StringVar(&cacheDir, "cache-dir", "", "directory for --cache (default: tree-pro under the user cache dir)")
+	rootCmd.Flags().BoolVar(&showFilteredCounts, "show-filtered-counts", false, "show how many entries filters removed from each directory")
+	rootCmd.Flags().IntVar(&width, "width", 0, "truncate tree lines to this many columns (0 for no limit)")
+	rootCmd.Flags().StringArrayVarP(&excludePatterns, "exclude", "E", nil, "hide files whose name matches this glob (repeatable)")
+	rootCmd.Flags().BoolVar(&showOnlyExcluded, "show-only-excluded", false, "show only the files --exclude would hide, to debug patterns")
+	rootCmd.Flags().StringVar(&expectFile, "expect", "", "mark paths listed in this file as present (✓) or missing (✗); fail if any are missing")
+	rootCmd.Flags().BoolVar(&fullPath, "full-path", false, "label every entry with its full path")
+	rootCmd.Flags().StringVar(&stripPrefix, "strip-prefix", "", "remove this leading string from --full-path labels")
+	rootCmd.Flags().StringVar(&urlBase, "base", "", "base URL for --format urls")
+	rootCmd.Flags().BoolVar(&urlDirs, "url-dirs", false, "with --format urls, also list each directory's index URL")
+	rootCmd.Flags().BoolVar(&collapseNumbered, "collapse-numbered", false, "fold sibling directories named like run_1, run_2, ... into one run_{1..N}/ entry")
+	rootCmd.Flags().BoolVar(&expandNumbered, "expand-numbered", false, "with --collapse-numbered, show the first member's contents")
+	rootCmd.Flags().BoolVar(&totals, "totals", false, "print totals by type: directories, files, symlinks, errors")
+	rootCmd.Flags().StringSliceVar(&totalsOmit, "totals-omit", nil, "comma-separated --totals categories to leave out")
+	rootCmd.Flags().BoolVar(&overview, "overview", false, "print a two-level outline with recursive counts before the full tree")
+	rootCmd.Flags().StringVar(&hideSizeOver, "hide-size-over", "", "hide files larger than this size (e.g. 10M) from the listing but keep them in totals")
+	rootCmd.Flags().StringVar(&writeSignatures, "write-signatures", "", "write each directory's structure signature to this file")
+	rootCmd.Flags().StringVar(&changedSince, "changed-since", "", "show only directories whose signature differs from this --write-signatures file")
+	rootCmd.Flags().BoolVar(&hyperlinks, "hyperlinks", false, "make entry names clickable file:// links in terminals that support OSC 8")
+	rootCmd.Flags().BoolVar(&byDate, "by-date", false, "group files in each directory by modification date (today, this week, older)")
+	rootCmd.Flags().StringVar(&dateBuckets, "date-buckets", "", "comma-separated bucket ages for --by-date, e.g. 1d,7d,30d")
+	rootCmd.Flags().BoolVar(&numbered, "numbered", false, "prefix each tree line with a line number for referencing")
+	rootCmd.Flags().BoolVar(&numberEntriesOnly, "numbered-entries-only", false, "with --numbered, leave the root and stats lines unnumbered")
+	rootCmd.Flags().StringVar(&anchor, "anchor", "", "walk the whole root but render only the subtree at this relative path")
+	rootCmd.Flags().BoolVar(&diskUsage, "du", false, "show the recursive size of each directory and the size of each file")
+	rootCmd.Flags().BoolVar(&diskUsageSort, "du-sort", false, "with --du, list the largest subdirectories and files first at every level; with --oneline, order the lines by size")
+	rootCmd.Flags().BoolVar(&skipSpecial, "skip-special", false, "omit FIFOs, sockets and device files instead of marking them")
+	rootCmd.Flags().IntVar(&indentWidth, "indent-width", 2, "spaces per level for --format indent")
+	rootCmd.Flags().IntVar(&foldAt, "fold-at", 0, "fold directories holding more than this many files recursively into one line (0 to disable)")
+	rootCmd.Flags().StringVar(&svgFont, "svg-font", "monospace", "font family for --format svg")
+	rootCmd.Flags().IntVar(&svgFontSize, "svg-font-size", 14, "font size in pixels for --format svg")
+	rootCmd.Flags().StringArrayVar(&stopAt, "stop-at", nil, "show directories whose name matches this glob but do not descend into them (repeatable)")
+	rootCmd.Flags().BoolVar(&heatmap, "heatmap", false, "color directory backgrounds from blue (few files) to red (many files)")
+	rootCmd.Flags().StringVar(&sinceCommit, "since-commit", "", "show only files changed since this git ref, and their directories")
+	rootCmd.Flags().BoolVar(&deterministic, "deterministic", false, "guarantee byte-identical output across runs, e.g. keep --exec output in path order")
+	rootCmd.Flags().IntVar(&previewLines, "preview", 0, "show the first N lines of small text files under their names")
+	rootCmd.Flags().StringVar(&previewMaxSize, "preview-max-size", "64K", "largest file --preview reads")
+	rootCmd.Flags().BoolVar(&balance, "balance", false, "print the maximum depth and the mean and spread of file depths")
+	rootCmd.Flags().BoolVar(&noEnvIgnore, "no-env-ignore", false, "ignore the default exclude globs in "+ignoreEnv)
+	rootCmd.Flags().BoolVar(&sizeBars, "bars", false, "with --du, draw a bar comparing each directory's size with its siblings")
+	rootCmd.Flags().IntVar(&maxComponents, "max-components", 0, "render only entries whose path, as written including the root, has at most N components (0 for no limit)")
+	rootCmd.Flags().BoolVar(&dumpInternal, "dump-internal", false, "print every field the walker computed (unstable debug output)")
+	rootCmd.Flags().MarkHidden("dump-internal")
+	rootCmd.Flags().BoolVar(&expandArchives, "expand-archives", false, "list the contents of .zip, .tar, .tar.gz and .tgz files as subtrees")
+	rootCmd.Flags().BoolVar(&nameStats, "name-stats", false, "print file-name length statistics and mark names longer than --name-limit")
+	rootCmd.Flags().IntVar(&nameLimit, "name-limit", 255, "with --name-stats, the longest acceptable file name in bytes (0 for no limit)")
+	rootCmd.Flags().DurationVar(&timeout, "timeout", 0, "stop walking after this long (e.g. 30s) and print the partial tree (0 for no limit)")
+	rootCmd.Flags().StringVar(&mergeRoots, "merge-roots", "", "walk every path and render them as one tree under a synthetic root named `LABEL`")
+	rootCmd.Flags().Lookup("merge-roots").NoOptDefVal = "merged"
+	rootCmd.Flags().StringVar(&lintNames, "lint-names", "", "mark files whose name does not match this regular expression and count them after the tree")
+	rootCmd.Flags().BoolVar(&failOnLint, "fail-on-lint", false, "with --lint-names, exit with an error when any file name does not match")
+	rootCmd.Flags().BoolVar(&sections, "sections", false, "insert a header before each alphabetical group of entries, like a phone book")
+	rootCmd.Flags().IntVar(&sectionChars, "section-chars", 1, "number of leading characters that form a --sections group")
+	rootCmd.Flags().BoolVar(&sectionCaseSensitive, "section-case-sensitive", false, "with --sections, keep upper- and lower-case initials in separate groups")
+	rootCmd.Flags().BoolVar(&annotateJSON, "annotate-json", false, "append a // {\"path\":...} JSON comment with each entry's metadata to every tree line")
+	rootCmd.Flags().IntVar(&signatureDepth, "signature-depth", 0, "ignore contents below this level when deciding which directories are identical (0 for full depth)")
+	rootCmd.Flags().BoolVar(&oneline, "oneline", false, "print one line of recursive totals per top-level directory instead of the tree (sorted by name, or by size with --du-sort)")
+	rootCmd.Flags().BoolVar(&packages, "packages", false, "label directories holding a package manifest with the package name and version and show each as one line")
+	rootCmd.Flags().BoolVar(&expandPackages, "expand-packages", false, "with --packages, keep showing the contents of package directories")
+	rootCmd.Flags().StringSliceVar(&packageManifests, "package-manifests", internal.DefaultPackageManifests, "comma-separated file names that mark a --packages package root")
+	rootCmd.Flags().BoolVar(&blame, "blame", false, "annotate files with their last git author and directories with their most frequent one (reads the whole git history once)")
+	rootCmd.Flags().StringVar(&emitIgnorePath, "emit-ignore", "", "write the effective exclude patterns in .gitignore syntax to `FILE` (stdout when omitted) instead of printing a tree")
+	rootCmd.Flags().Lookup("emit-ignore").NoOptDefVal = "-"
+	rootCmd.Flags().StringVar(&labelTemplate, "label-template", "", "Go text/template for directory labels, e.g. '{{.Name}}/ ({{.TotalFiles}})'")
+	rootCmd.Flags().StringVar(&fileLabelTemplate, "file-label-template", "", "Go text/template for file labels, e.g. '{{.Name}} {{.HumanSize}}'")
+	rootCmd.Flags().Float64Var(&similar, "similar", 1, "also collapse sibling directories whose structures are at least this similar, from 0 to 1 (1 for identical only)")
+	rootCmd.Flags().StringVar(&siteDir, "site", "", "write a static HTML site with one linked page per directory into this directory instead of printing the tree")
+	rootCmd.Flags().StringVar(&gitExtChurn, "git-ext-churn", "", "print how many files of each extension changed since this git ref after the tree")
+	rootCmd.Flags().StringVar(&rootSlash, "root-slash", rootSlashAuto, "trailing separator on the root label: auto (none for \".\"), always or never")
+	rootCmd.Flags().StringVar(&grepPattern, "grep", "", "show only files with a line matching this regular expression, and the directories holding them")
+	rootCmd.Flags().StringVar(&grepMaxSize, "grep-max-size", "1M", "largest file --grep searches")
+	rootCmd.Flags().BoolVar(&grepCount, "grep-count", false, "with --grep, show how many lines of each file match")
+	rootCmd.Flags().BoolVar(&shape, "shape", false, "hide all names: draw files as dots and directories as their file count")
+	rootCmd.Flags().BoolVar(&autofit, "autofit", false, "limit the depth so the tree fits the terminal height (from LINES or the terminal; unlimited when unknown)")
+	rootCmd.Flags().BoolVar(&useGitignore, "gitignore", false, "skip files and directories matched by the .gitignore files found while walking")
+	rootCmd.Flags().IntVar(&concurrency, "concurrency", 0, "read up to `N` directories at once (0 means one per CPU, 1 walks serially)")
+	rootCmd.Flags().BoolVar(&diskUsage, "size", false, "same as --du")
+	rootCmd.Flags().StringArrayVarP(&includePatterns, "include", "I", nil, "show only files whose name matches this glob (repeatable); --exclude still wins")
+	rootCmd.Flags().BoolVar(&followSymlinks, "follow-symlinks", false, "walk into symbolic links to directories, stopping at links that lead back to a directory being walked")
+	rootCmd.Flags().StringVar(&colorMode, "color", colorAuto, "when to color the output: auto (only on a terminal and without NO_COLOR), always or never")
+	rootCmd.Flags().StringVar(&charset, "charset", internal.CharsetUnicode, "connector characters: unicode, ascii (|-- and `--) or rounded (same as --rounded)")
+	rootCmd.Flags().BoolVarP(&reverse, "reverse", "r", false, "reverse the --sort order")
+	rootCmd.Flags().BoolVar(&dirsFirst, "dirs-first", true, "list each directory's subdirectories before its files; --dirs-first=false merges them in --sort order")
+	rootCmd.Flags().BoolVarP(&showHidden, "all", "a", false, "list entries whose name starts with '.', such as .git and .env")
+	rootCmd.Flags().BoolVarP(&dirsOnly, "dirs-only", "D", false, "list directories only; files still count toward the totals")
+	rootCmd.Flags().Float64Var(&similar, "similarity", 1, "same as --similar")
 }
 
-func formatRootLabel(input string) string {
+func walk(ctx context.Context, path string, opts internal.Options) (*internal.Directory, error) {
+	if !useCache {
+		return internal.WalkContext(ctx, path, opts)
+	}
+	cache, err := internal.NewCache(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	return cache.WalkContext(ctx, path, opts)
+}
+
+// pathComponents counts the segments of a cleaned path as written, so "."
+// has none, "src/app" two and "/srv/data" two.
+func pathComponents(path string) int {
+	if path == "." {
+		return 0
+	}
+	return len(strings.FieldsFunc(path, func(r rune) bool { return os.IsPathSeparator(uint8(r)) }))
+}
+
+// withEnvIgnore prepends the globs from TREE_PRO_IGNORE to the --exclude
+// patterns when useEnv is set.
+func withEnvIgnore(patterns []string, useEnv bool) []string {
+	if !useEnv {
+		return patterns
+	}
+	return append(envIgnorePatterns(), patterns...)
+}
+
+// envIgnorePatterns returns the globs in TREE_PRO_IGNORE, separated by ':'
+// or ','.
+func envIgnorePatterns() []string {
+	var patterns []string
+	for _, pattern := range strings.FieldsFunc(os.Getenv(ignoreEnv), func(r rune) bool { return r == ':' || r == ',' }) {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// emitIgnore writes the effective exclude patterns in .gitignore syntax to
+// stdout, or to path unless it is "-".
+func emitIgnore(cmd *cobra.Command, path string) error {
+	var sources []internal.IgnoreSource
+	if !noEnvIgnore {
+		sources = append(sources, internal.IgnoreSource{Name: ignoreEnv, Patterns: envIgnorePatterns()})
+	}
+	sources = append(sources, internal.IgnoreSource{Name: "--exclude", Patterns: excludePatterns})
+	if path == "-" {
+		return internal.WriteIgnore(cmd.OutOrStdout(), sources)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := internal.WriteIgnore(f, sources); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Supported values for --color.
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// useColor resolves --color for output written to out. In auto mode color is
+// used only when out is a terminal and NO_COLOR is unset.
+func useColor(out io.Writer) bool {
+	switch colorMode {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	f, ok := out.(*os.File)
+	return ok && internal.IsTerminal(f)
+}
+
+// terminalHeight returns the number of rows available for output: LINES
+// when set, otherwise the height of the terminal on stdout, or 0 if unknown.
+func terminalHeight() int {
+	if lines, err := strconv.Atoi(os.Getenv("LINES")); err == nil && lines > 0 {
+		return lines
+	}
+	return internal.TerminalHeight(os.Stdout)
+}
+
+func runExec(cmd *cobra.Command, paths []string, useColor bool) error {
+	result, err := internal.RunExec(execCommand, paths, execParallel, deterministic, cmd.OutOrStdout())
+	if err != nil {
+		return err
+	}
+	internal.PrintExecFooter(cmd.OutOrStdout(), result, useColor)
+	if len(result.Failures) > 0 {
+		return fmt.Errorf("%d of %d exec commands failed", len(result.Failures), result.Runs)
+	}
+	return nil
+}
+
+// Supported values for --root-slash.
+const (
+	rootSlashAuto   = "auto"
+	rootSlashAlways = "always"
+	rootSlashNever  = "never"
+)
+
+// formatRootLabel returns the label printed for the root path input. With
+// rootSlashAuto, "." stays ".", input already ending in a separator is kept
+// as written and anything else gets one trailing separator. rootSlashAlways
+// and rootSlashNever label the cleaned path with and without a trailing
+// separator; a filesystem root such as "/" always keeps its own.
+func formatRootLabel(input, mode string) string {
 	if input == "" {
 		input = "."
 	}
 	cleaned := filepath.Clean(input)
+	sep := string(os.PathSeparator)
+	withSlash := cleaned
+	if !strings.HasSuffix(cleaned, sep) {
+		withSlash += sep
+	}
+
+	switch mode {
+	case rootSlashAlways:
+		return withSlash
+	case rootSlashNever:
+		return cleaned
+	}
 	if cleaned == "." {
 		return cleaned
 	}
-
-	sep := string(os.PathSeparator)
 	if strings.HasSuffix(input, sep) {
 		return input
 	}
-	return cleaned + sep
+	return withSlash
 }
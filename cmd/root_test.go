@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// --max-components counts the root as written, so the depth it allows is N
+// minus these components; -L counts levels below the root instead.
+func TestPathComponents(t *testing.T) {
+	tests := []struct {
+		path string
+		want int
+	}{
+		{".", 0},
+		{"src", 1},
+		{filepath.Join("src", "app"), 2},
+		{filepath.Clean("src/app/"), 2},
+		{filepath.Join(string(filepath.Separator), "var", "log"), 2},
+		{filepath.Join("a", "b", "c", "d"), 4},
+	}
+	for _, tt := range tests {
+		if got := pathComponents(tt.path); got != tt.want {
+			t.Errorf("pathComponents(%q) = %d, want %d", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFormatRootLabel(t *testing.T) {
+	sep := string(filepath.Separator)
+	tests := []struct {
+		input, mode, want string
+	}{
+		{".", rootSlashAuto, "."},
+		{".", rootSlashAlways, "." + sep},
+		{".", rootSlashNever, "."},
+		{"", rootSlashAuto, "."},
+		{sep, rootSlashAuto, sep},
+		{sep, rootSlashAlways, sep},
+		{sep, rootSlashNever, sep},
+		{"src", rootSlashAuto, "src" + sep},
+		{"src", rootSlashAlways, "src" + sep},
+		{"src", rootSlashNever, "src"},
+		{"src" + sep, rootSlashAuto, "src" + sep},
+		{"src" + sep + sep, rootSlashAuto, "src" + sep + sep},
+		{"src" + sep, rootSlashNever, "src"},
+		{filepath.Join("a", "b") + sep, rootSlashAlways, filepath.Join("a", "b") + sep},
+	}
+	if runtime.GOOS == "windows" {
+		tests = append(tests, []struct {
+			input, mode, want string
+		}{
+			{`C:\`, rootSlashAuto, `C:\`},
+			{`C:\`, rootSlashNever, `C:\`},
+			{`C:\src\app`, rootSlashAuto, `C:\src\app\`},
+			{`src/app/`, rootSlashAuto, `src\app\`},
+			{`src\app\`, rootSlashNever, `src\app`},
+		}...)
+	} else {
+		// Backslashes are ordinary name characters outside Windows.
+		tests = append(tests, []struct {
+			input, mode, want string
+		}{
+			{`src\app`, rootSlashAuto, `src\app/`},
+			{`src\`, rootSlashNever, `src\`},
+		}...)
+	}
+	for _, tt := range tests {
+		if got := formatRootLabel(tt.input, tt.mode); got != tt.want {
+			t.Errorf("formatRootLabel(%q, %q) = %q, want %q", tt.input, tt.mode, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Djanghao/tree-pro/internal"
+)
+
+var (
+	whyExcludePatterns []string
+	whyShowHidden      bool
+)
+
+var whyCmd = &cobra.Command{
+	Use:   "why PATH_A PATH_B",
+	Short: "Explain why two directories do or do not collapse as identical",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := internal.Options{ExcludePatterns: withEnvIgnore(whyExcludePatterns, true), ShowHidden: whyShowHidden}
+		var dirs [2]*internal.Directory
+		for idx, path := range args {
+			dir, err := internal.Walk(path, opts)
+			if err != nil {
+				return err
+			}
+			if dir.Err != nil {
+				return fmt.Errorf("%s: %w", path, dir.Err)
+			}
+			dirs[idx] = dir
+		}
+		internal.ExplainSignatures(cmd.OutOrStdout(), dirs[0], dirs[1])
+		return nil
+	},
+}
+
+func init() {
+	whyCmd.Flags().StringArrayVarP(&whyExcludePatterns, "exclude", "E", nil, "ignore files whose name matches this glob, as the main command does (repeatable)")
+	whyCmd.Flags().BoolVarP(&whyShowHidden, "all", "a", false, "include entries whose name starts with '.', as the main command does")
+	rootCmd.AddCommand(whyCmd)
+}
@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// FindDirectory returns the directory at subpath below root, following
+// Subdirs by name. It also returns the names along the way, root excluded,
+// for use as a breadcrumb.
+func FindDirectory(root *Directory, subpath string) (*Directory, []string, error) {
+	dir := root
+	var crumbs []string
+	for _, name := range strings.Split(filepath.ToSlash(filepath.Clean(subpath)), "/") {
+		if name == "." || name == "" {
+			continue
+		}
+		if name == ".." {
+			return nil, nil, fmt.Errorf("anchor %q leaves the root", subpath)
+		}
+		next := childNamed(dir, name)
+		if next == nil {
+			return nil, nil, fmt.Errorf("anchor %q not found in the walked tree", subpath)
+		}
+		dir = next
+		crumbs = append(crumbs, name)
+	}
+	return dir, crumbs, nil
+}
+
+func childNamed(dir *Directory, name string) *Directory {
+	for _, child := range dir.Subdirs {
+		if child.Name == name {
+			return child
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"encoding/json"
+	"path/filepath"
+)
+
+// lineMeta is the metadata AnnotateJSON appends to a directory or file line.
+// Dirs and Files are only set for directories.
+type lineMeta struct {
+	Path  string `json:"path"`
+	Type  string `json:"type"`
+	Size  int64  `json:"size"`
+	Dirs  *int   `json:"dirs,omitempty"`
+	Files *int   `json:"files,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// dirMetaComment returns the trailing JSON comment for a directory line. It
+// is built from the raw tree, so no color escapes can reach it.
+func dirMetaComment(dir *Directory, opts PrinterOptions) string {
+	if !opts.AnnotateJSON {
+		return ""
+	}
+	meta := lineMeta{
+		Path:  filepath.ToSlash(dir.Path),
+		Type:  "dir",
+		Size:  dir.TotalSize,
+		Dirs:  &dir.TotalDirs,
+		Files: &dir.TotalFiles,
+	}
+	if dir.Err != nil {
+		meta.Error = errorText(dir)
+	}
+	return metaComment(meta)
+}
+
+// fileMetaComment returns the trailing JSON comment for a file line.
+func fileMetaComment(dir *Directory, file FileEntry, opts PrinterOptions) string {
+	if !opts.AnnotateJSON || file.Ghost {
+		return ""
+	}
+	return metaComment(lineMeta{
+		Path: filepath.ToSlash(filepath.Join(dir.Path, file.Name)),
+		Type: "file",
+		Size: file.Size,
+	})
+}
+
+func metaComment(meta lineMeta) string {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return ""
+	}
+	return " // " + string(data)
+}
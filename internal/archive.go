@@ -0,0 +1,145 @@
+package internal
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// isArchive reports whether name looks like an archive ExpandArchives opens.
+func isArchive(name string) bool {
+	lower := strings.ToLower(name)
+	for _, suffix := range []string{".zip", ".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+type archiveEntry struct {
+	name  string
+	size  int64
+	isDir bool
+}
+
+// readArchive lists the archive at path as a Directory named name at level,
+// built like BuildFromPaths. Paths inside it are joined onto the archive's
+// own path. Archives nested inside it are listed as plain files and never
+// opened, which bounds the recursion. MaxFiles truncation applies as for
+// real directories.
+func readArchive(path, name string, level int, opts Options) (*Directory, error) {
+	entries, err := archiveEntries(path)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(entries))
+	sizes := make(map[string]int64, len(entries))
+	for _, entry := range entries {
+		if entry.isDir {
+			paths = append(paths, strings.TrimSuffix(entry.name, "/")+"/")
+			continue
+		}
+		paths = append(paths, entry.name)
+		sizes[strings.TrimPrefix(entry.name, "/")] = entry.size
+	}
+
+	root := BuildFromPaths(name, paths)
+	root.Archive = true
+	finishArchiveDir(root, path, "", level, sizes, opts)
+	return root, nil
+}
+
+// finishArchiveDir rebases a built archive tree onto the filesystem: real
+// paths, levels and sizes, and MaxFiles truncation.
+func finishArchiveDir(dir *Directory, base, rel string, level int, sizes map[string]int64, opts Options) {
+	dir.Path = filepath.Join(base, filepath.FromSlash(rel))
+	dir.Level = level
+	dir.TotalSize = 0
+	for idx := range dir.Files {
+		file := &dir.Files[idx]
+		file.Size = sizes[joinSlash(rel, file.Name)]
+		dir.TotalSize += file.Size
+	}
+	if opts.MaxFiles > 0 && len(dir.Files) > opts.MaxFiles {
+		dir.HiddenFiles = len(dir.Files) - opts.MaxFiles
+		dir.Files = dir.Files[:opts.MaxFiles]
+	}
+	for _, child := range dir.Subdirs {
+		finishArchiveDir(child, base, joinSlash(rel, child.Name), level+1, sizes, opts)
+		dir.TotalSize += child.TotalSize
+	}
+}
+
+func joinSlash(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
+
+func archiveEntries(path string) ([]archiveEntry, error) {
+	if strings.HasSuffix(strings.ToLower(path), ".zip") {
+		return zipEntries(path)
+	}
+	return tarEntries(path)
+}
+
+func zipEntries(path string) ([]archiveEntry, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	entries := make([]archiveEntry, 0, len(reader.File))
+	for _, file := range reader.File {
+		entries = append(entries, archiveEntry{
+			name:  file.Name,
+			size:  int64(file.UncompressedSize64),
+			isDir: file.FileInfo().IsDir(),
+		})
+	}
+	return entries, nil
+}
+
+func tarEntries(path string) ([]archiveEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	lower := strings.ToLower(path)
+	if strings.HasSuffix(lower, ".gz") || strings.HasSuffix(lower, ".tgz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var entries []archiveEntry
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			entries = append(entries, archiveEntry{name: header.Name, isDir: true})
+		case tar.TypeReg, tar.TypeSymlink, tar.TypeLink:
+			entries = append(entries, archiveEntry{name: header.Name, size: header.Size})
+		}
+	}
+}
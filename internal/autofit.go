@@ -0,0 +1,51 @@
+package internal
+
+import "bytes"
+
+// FitDepth returns the largest MaxDepth at which PrintTree renders dir in at
+// most lines lines, or 0 when the whole tree fits. The tree is rendered
+// without color once per depth tried, from 1 down to the full depth of the
+// tree or opts.MaxDepth if that is lower. When even depth 1 is too tall, 1 is
+// returned so the top level is still shown.
+func FitDepth(rootLabel string, dir *Directory, opts PrinterOptions, lines int) (int, error) {
+	// Files below the deepest directory need one level more.
+	full := treeDepth(dir) + 1
+	deepest := full
+	if opts.MaxDepth > 0 {
+		deepest = min(deepest, opts.MaxDepth)
+	}
+	opts.UseColor = false
+	var counter lineCounter
+	opts.Writer = &counter
+	for depth := 1; depth <= deepest; depth++ {
+		counter = 0
+		opts.MaxDepth = depth
+		if err := PrintTree(rootLabel, dir, opts); err != nil {
+			return 0, err
+		}
+		if int(counter) > lines {
+			return max(depth-1, 1), nil
+		}
+	}
+	if deepest == full {
+		return 0, nil
+	}
+	return deepest, nil
+}
+
+// treeDepth returns how many levels of subdirectories lie below dir.
+func treeDepth(dir *Directory) int {
+	depth := 0
+	for _, child := range dir.Subdirs {
+		depth = max(depth, treeDepth(child)+1)
+	}
+	return depth
+}
+
+// lineCounter is an io.Writer that only counts newlines.
+type lineCounter int
+
+func (c *lineCounter) Write(p []byte) (int, error) {
+	*c += lineCounter(bytes.Count(p, []byte{'\n'}))
+	return len(p), nil
+}
@@ -0,0 +1,45 @@
+package internal
+
+import "math"
+
+// BalanceStats summarizes how deeply files are nested. Depths count levels
+// below the root: a file directly inside the root has depth 1.
+type BalanceStats struct {
+	// MaxDepth is the depth of the deepest directory or file.
+	MaxDepth int `json:"maxDepth"`
+	Files    int `json:"files"`
+	// MeanFileDepth and StdDevFileDepth describe the distribution of file
+	// depths; both are 0 for a tree without files.
+	MeanFileDepth   float64 `json:"meanFileDepth"`
+	StdDevFileDepth float64 `json:"stdDevFileDepth"`
+}
+
+// TreeBalance computes BalanceStats for root. Every file counts, including
+// files hidden by MaxFiles truncation, but directories that were not read
+// contribute only their own depth.
+func TreeBalance(root *Directory) BalanceStats {
+	var stats BalanceStats
+	var sum, sumSquares float64
+	var visit func(dir *Directory)
+	visit = func(dir *Directory) {
+		depth := dir.Level - root.Level
+		stats.MaxDepth = max(stats.MaxDepth, depth)
+		if files := dir.ImmediateFileCount; files > 0 {
+			stats.MaxDepth = max(stats.MaxDepth, depth+1)
+			stats.Files += files
+			sum += float64(files * (depth + 1))
+			sumSquares += float64(files * (depth + 1) * (depth + 1))
+		}
+		for _, child := range dir.Subdirs {
+			visit(child)
+		}
+	}
+	visit(root)
+
+	if stats.Files > 0 {
+		n := float64(stats.Files)
+		stats.MeanFileDepth = sum / n
+		stats.StdDevFileDepth = math.Sqrt(math.Max(sumSquares/n-stats.MeanFileDepth*stats.MeanFileDepth, 0))
+	}
+	return stats
+}
@@ -0,0 +1,27 @@
+package internal
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestBalanceInJSON(t *testing.T) {
+	dir := BuildFromPaths("root", []string{"a.txt", "x/b.txt", "x/y/c.txt", "x/y/d.txt"})
+	out := render(t, dir, PrinterOptions{Format: FormatJSON, Balance: true})
+
+	var node struct {
+		Balance *BalanceStats `json:"balance"`
+	}
+	if err := json.Unmarshal([]byte(out), &node); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if node.Balance == nil {
+		t.Fatalf("root object has no balance:\n%s", out)
+	}
+	if got, want := *node.Balance, TreeBalance(dir); got != want {
+		t.Errorf("balance = %+v, want %+v", got, want)
+	}
+	if node.Balance.MaxDepth != 3 || node.Balance.Files != 4 || node.Balance.MeanFileDepth != 2.25 {
+		t.Errorf("balance = %+v, want max depth 3, 4 files, mean depth 2.25", *node.Balance)
+	}
+}
@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// GitFileAuthors maps every file under dir that is in the git index to the
+// author of the last commit touching it. Keys are dir joined with the file's
+// path, the way the walker builds paths. The whole history is read in one
+// "git log --name-only" pass rather than one git call per file; files it
+// names that have since been deleted are dropped.
+func GitFileAuthors(dir string) (map[string]string, error) {
+	if _, err := runGit(dir, "rev-parse", "--is-inside-work-tree"); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, fmt.Errorf("git is not installed")
+		}
+		return nil, fmt.Errorf("%s is not inside a git repository", dir)
+	}
+	output, err := runGit(dir, "-c", "core.quotePath=false", "log", "--format=%x00%an", "--name-only", "--no-renames", "--relative", "--", ".")
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+
+	listed, err := runGit(dir, "ls-files", "-z", "--", ".")
+	if err != nil {
+		return nil, fmt.Errorf("git ls-files: %w", err)
+	}
+	tracked := make(map[string]bool)
+	for _, line := range strings.Split(string(listed), "\x00") {
+		if line != "" {
+			tracked[line] = true
+		}
+	}
+
+	authors := make(map[string]string)
+	author := ""
+	for _, line := range strings.Split(string(output), "\n") {
+		if name, ok := strings.CutPrefix(line, "\x00"); ok {
+			author = name
+			continue
+		}
+		if line == "" || !tracked[line] {
+			continue
+		}
+		// Commits are listed newest first, so the first author seen for a
+		// path made its last change.
+		path := filepath.Join(dir, filepath.FromSlash(line))
+		if _, seen := authors[path]; !seen {
+			authors[path] = author
+		}
+	}
+	return authors, nil
+}
+
+// dominantAuthors returns, for every directory holding at least one file in
+// authors, the author of most of the files below it. Ties go to the name that
+// sorts first.
+func dominantAuthors(root *Directory, authors map[string]string) map[string]string {
+	counts := make(map[string]map[string]int)
+	for path, author := range authors {
+		for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+			if counts[dir] == nil {
+				counts[dir] = make(map[string]int)
+			}
+			counts[dir][author]++
+			if dir == root.Path || dir == filepath.Dir(dir) {
+				break
+			}
+		}
+	}
+
+	dominant := make(map[string]string, len(counts))
+	for dir, byAuthor := range counts {
+		names := make([]string, 0, len(byAuthor))
+		for name := range byAuthor {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		best := names[0]
+		for _, name := range names[1:] {
+			if byAuthor[name] > byAuthor[best] {
+				best = name
+			}
+		}
+		dominant[dir] = best
+	}
+	return dominant
+}
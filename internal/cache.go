@@ -0,0 +1,206 @@
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheVersion is bumped whenever the cached representation changes so stale
+// files from older releases are ignored.
+const cacheVersion = 4
+
+// Cache stores walked trees on disk, keyed by the absolute root path and the
+// walk options. An entry is reused only while the modification time of every
+// walked directory is unchanged. Directory mtimes change when entries are
+// added, removed or renamed, but not when a file's contents change, so cached
+// file sizes can lag behind in-place edits.
+type Cache struct {
+	Dir string
+}
+
+// NewCache returns a cache rooted at dir, defaulting to a tree-pro directory
+// under the user cache directory when dir is empty.
+func NewCache(dir string) (*Cache, error) {
+	if dir == "" {
+		base, err := os.UserCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(base, "tree-pro")
+	}
+	return &Cache{Dir: dir}, nil
+}
+
+type cacheFile struct {
+	Version int
+	Root    cachedDir
+	// ModTimes records the mtime of every walked directory, keyed by path.
+	ModTimes map[string]time.Time
+}
+
+type cachedDir struct {
+	Dir           Directory
+	Subdirs       []cachedDir
+	ErrText       string
+	ErrPermission bool
+}
+
+// Walk is WalkContext without cancellation.
+func (c *Cache) Walk(path string, opts Options) (*Directory, error) {
+	return c.WalkContext(context.Background(), path, opts)
+}
+
+// WalkContext returns the cached tree for path when it is still fresh, and
+// otherwise walks the filesystem with WalkContext and refreshes the cache.
+// Partial trees from a cancelled walk are never stored. Cache read and write
+// failures never fail the walk. Options with a SignatureFunc, which cannot be
+// part of the cache key, bypass the cache, and so do PreviewLines,
+// ExpandArchives, ContentPattern and UseGitignore, since editing a file does
+// not change its directory's mtime.
+func (c *Cache) WalkContext(ctx context.Context, path string, opts Options) (*Directory, error) {
+	if opts.SignatureFunc != nil || opts.PreviewLines > 0 || opts.ExpandArchives || opts.ContentPattern != nil || opts.UseGitignore {
+		return WalkContext(ctx, path, opts)
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return WalkContext(ctx, path, opts)
+	}
+	file := filepath.Join(c.Dir, cacheKey(abs, opts)+".gob")
+
+	if dir, ok := c.load(file); ok {
+		return dir, nil
+	}
+
+	dir, err := WalkContext(ctx, path, opts)
+	if err != nil {
+		return dir, err
+	}
+	c.store(file, dir)
+	return dir, nil
+}
+
+func cacheKey(abs string, opts Options) string {
+	hasher := sha256.New()
+	fmt.Fprintf(
+		hasher,
+		"v%d\x00%s\x00%d\x00%d\x00%s\x00%d\x00%d\x00%d\x00%t\x00%q\x00%t\x00%d\x00%t\x00%q\x00%d\x00%q\x00%q\x00%t\x00%t\x00%t\x00%t",
+		cacheVersion,
+		abs,
+		opts.MaxFiles,
+		opts.MaxLevel,
+		opts.SortBy,
+		opts.SkipOver,
+		opts.SampleFiles,
+		opts.Seed,
+		opts.OneFilesystem,
+		opts.ExcludePatterns,
+		opts.ShowOnlyExcluded,
+		opts.HideSizeOver,
+		opts.SkipSpecial,
+		opts.StopAt,
+		opts.SignatureDepth,
+		opts.PackageManifests,
+		opts.IncludePatterns,
+		opts.FollowSymlinks,
+		opts.Reverse,
+		opts.ShowHidden,
+		opts.DirsOnly,
+	)
+	return fmt.Sprintf("%x", hasher.Sum(nil))
+}
+
+func (c *Cache) load(file string) (*Directory, bool) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+
+	var cached cacheFile
+	if err := gob.NewDecoder(f).Decode(&cached); err != nil || cached.Version != cacheVersion {
+		return nil, false
+	}
+	for path, modTime := range cached.ModTimes {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Equal(modTime) {
+			return nil, false
+		}
+	}
+	return thawDir(cached.Root), true
+}
+
+func (c *Cache) store(file string, dir *Directory) {
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return
+	}
+	cached := cacheFile{
+		Version:  cacheVersion,
+		Root:     freezeDir(dir),
+		ModTimes: make(map[string]time.Time),
+	}
+	recordModTimes(dir, cached.ModTimes)
+
+	tmp, err := os.CreateTemp(c.Dir, "tmp-*")
+	if err != nil {
+		return
+	}
+	if err := gob.NewEncoder(tmp).Encode(cached); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+func recordModTimes(dir *Directory, modTimes map[string]time.Time) {
+	if dir.Err != nil || dir.ModTime.IsZero() {
+		return
+	}
+	modTimes[dir.Path] = dir.ModTime
+	for _, child := range dir.Subdirs {
+		recordModTimes(child, modTimes)
+	}
+}
+
+func freezeDir(dir *Directory) cachedDir {
+	frozen := cachedDir{Dir: *dir}
+	frozen.Dir.Subdirs = nil
+	frozen.Dir.Err = nil
+	if dir.Err != nil {
+		frozen.ErrText = dir.Err.Error()
+		frozen.ErrPermission = dir.IsPermissionError()
+	}
+	for _, child := range dir.Subdirs {
+		frozen.Subdirs = append(frozen.Subdirs, freezeDir(child))
+	}
+	return frozen
+}
+
+func thawDir(frozen cachedDir) *Directory {
+	dir := frozen.Dir
+	switch {
+	case frozen.ErrPermission:
+		dir.Err = &fs.PathError{Op: "open", Path: dir.Path, Err: fs.ErrPermission}
+	case frozen.ErrText != "":
+		dir.Err = errors.New(frozen.ErrText)
+	}
+	dir.Subdirs = make([]*Directory, 0, len(frozen.Subdirs))
+	for _, child := range frozen.Subdirs {
+		dir.Subdirs = append(dir.Subdirs, thawDir(child))
+	}
+	return &dir
+}
@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"path/filepath"
+)
+
+type d3Graph struct {
+	Nodes []d3Node `json:"nodes"`
+	Links []d3Link `json:"links"`
+}
+
+type d3Node struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Path  string `json:"path,omitempty"`
+	Size  int64  `json:"size"`
+	Count int    `json:"count,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type d3Link struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// renderD3 writes the tree as a flat node and link list for graph libraries
+// such as D3.js. Node ids are hashes of paths relative to the root, so they
+// stay stable between runs. Node types are "dir", "file", "error",
+// "collapsed" (identical directories folded by MaxDirs and numbered groups
+// folded by CollapseNumbered) and "hidden" (files truncated by MaxFiles or
+// elided by PathTo); the latter two carry a count. Files grouped by date
+// buckets are listed as plain files and section headers are left out.
+func renderD3(w io.Writer, rootLabel string, dir *Directory, opts PrinterOptions) error {
+	graph := d3Graph{Nodes: []d3Node{}, Links: []d3Link{}}
+	addD3Dir(&graph, dir, rootLabel, ".", opts)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(graph)
+}
+
+func addD3Dir(graph *d3Graph, dir *Directory, name, rel string, opts PrinterOptions) string {
+	id := pathID(rel)
+	node := d3Node{ID: id, Name: name, Type: "dir", Path: filepath.ToSlash(rel), Size: dir.TotalSize}
+	if dir.Err != nil {
+		node.Type = "error"
+		node.Error = errorText(dir)
+	}
+	graph.Nodes = append(graph.Nodes, node)
+	if dir.Err != nil {
+		return id
+	}
+
+	for idx, item := range buildItems(dir, opts) {
+		switch item.kind {
+		case itemDir:
+			childRel := filepath.Join(rel, item.dir.Name)
+			linkD3(graph, id, addD3Dir(graph, item.dir, item.dir.Name, childRel, opts))
+		case itemFile:
+			addD3File(graph, id, rel, item.file)
+		case itemDateGroup:
+			for _, file := range item.dateGroup.files {
+				addD3File(graph, id, rel, file)
+			}
+		case itemCollapse:
+			addD3Summary(graph, id, rel, idx, "collapsed", itemSummary(dir, item), item.collapseCount)
+		case itemNumbered:
+			addD3Summary(graph, id, rel, idx, "collapsed", itemSummary(dir, item), len(item.numbered.Members))
+		case itemFileSummary, itemElided:
+			addD3Summary(graph, id, rel, idx, "hidden", itemSummary(dir, item), item.collapseCount)
+		}
+	}
+	return id
+}
+
+func addD3File(graph *d3Graph, parentID, rel string, file FileEntry) {
+	childRel := filepath.Join(rel, file.Name)
+	childID := pathID(childRel)
+	graph.Nodes = append(graph.Nodes, d3Node{
+		ID:   childID,
+		Name: file.Name,
+		Type: "file",
+		Path: filepath.ToSlash(childRel),
+		Size: file.Size,
+	})
+	linkD3(graph, parentID, childID)
+}
+
+func addD3Summary(graph *d3Graph, parentID, rel string, idx int, kind, name string, count int) {
+	childID := pathID(fmt.Sprintf("%s#%s:%d", rel, kind, idx))
+	graph.Nodes = append(graph.Nodes, d3Node{
+		ID:    childID,
+		Name:  name,
+		Type:  kind,
+		Count: count,
+	})
+	linkD3(graph, parentID, childID)
+}
+
+func linkD3(graph *d3Graph, source, target string) {
+	graph.Links = append(graph.Links, d3Link{Source: source, Target: target})
+}
+
+func pathID(rel string) string {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(filepath.ToSlash(rel)))
+	return fmt.Sprintf("%016x", hasher.Sum64())
+}
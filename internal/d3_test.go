@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestD3NodeTypes(t *testing.T) {
+	dir := BuildFromPaths("root", []string{"run_1/a.go", "run_2/a.go", "run_3/b.md", "apple.txt", "avocado.txt", "banana.txt"})
+	opts := PrinterOptions{
+		Format:           FormatD3,
+		CollapseNumbered: true,
+		SectionChars:     1,
+		DateBuckets:      DefaultDateBuckets(time.Now()),
+	}
+	var graph d3Graph
+	if err := json.Unmarshal([]byte(render(t, dir, opts)), &graph); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := map[string]int{}
+	for _, node := range graph.Nodes {
+		counts[node.Type]++
+		if node.Type == "hidden" {
+			t.Errorf("unexpected hidden node %+v", node)
+		}
+		if node.Type == "collapsed" && node.Count != 3 {
+			t.Errorf("numbered group %q has count %d, want 3", node.Name, node.Count)
+		}
+	}
+	if want := map[string]int{"dir": 1, "collapsed": 1, "file": 3}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("node types = %v, want %v", counts, want)
+	}
+
+	var files []string
+	for _, node := range graph.Nodes {
+		if node.Type == "file" {
+			files = append(files, node.Path)
+		}
+	}
+	sort.Strings(files)
+	if want := []string{"apple.txt", "avocado.txt", "banana.txt"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("files = %v, want %v", files, want)
+	}
+	if len(graph.Links) != len(graph.Nodes)-1 {
+		t.Errorf("%d links for %d nodes, want one per non-root node", len(graph.Links), len(graph.Nodes))
+	}
+
+	// Without date buckets the files are split into sections, whose
+	// headers are not nodes.
+	opts.DateBuckets = nil
+	graph = d3Graph{}
+	if err := json.Unmarshal([]byte(render(t, dir, opts)), &graph); err != nil {
+		t.Fatal(err)
+	}
+	for _, node := range graph.Nodes {
+		if node.Type != "dir" && node.Type != "collapsed" && node.Type != "file" {
+			t.Errorf("unexpected %s node %q", node.Type, node.Name)
+		}
+	}
+	if len(graph.Nodes) != 5 {
+		t.Errorf("%d nodes with sections, want 5", len(graph.Nodes))
+	}
+}
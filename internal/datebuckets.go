@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateBucket groups files modified at or after Since. Buckets are checked in
+// order, so they should be listed from newest to oldest.
+type DateBucket struct {
+	Label string
+	Since time.Time
+}
+
+// olderBucket labels files that fall in no DateBucket.
+const olderBucket = "older"
+
+// DefaultDateBuckets returns the "today" (since local midnight) and
+// "this week" (last seven days) buckets relative to now.
+func DefaultDateBuckets(now time.Time) []DateBucket {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return []DateBucket{
+		{Label: "today", Since: midnight},
+		{Label: "this week", Since: now.AddDate(0, 0, -7)},
+	}
+}
+
+// ParseDateBuckets builds buckets from a comma-separated list of ages such as
+// "1d,7d,30d", labelled "last 1d", "last 7d" and so on. Ages accept the units
+// of time.ParseDuration plus "d" (days) and "w" (weeks), and are sorted
+// youngest first.
+func ParseDateBuckets(spec string, now time.Time) ([]DateBucket, error) {
+	var buckets []DateBucket
+	var previous time.Duration
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		age, err := ParseAge(part)
+		if err != nil {
+			return nil, err
+		}
+		if age <= previous {
+			return nil, fmt.Errorf("date buckets must be increasing, got %q", part)
+		}
+		previous = age
+		buckets = append(buckets, DateBucket{Label: "last " + part, Since: now.Add(-age)})
+	}
+	return buckets, nil
+}
+
+// ParseAge parses a duration, additionally accepting whole days ("3d") and
+// weeks ("2w").
+func ParseAge(text string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(text, suffix); ok {
+			value, err := strconv.Atoi(n)
+			if err != nil || value <= 0 {
+				return 0, fmt.Errorf("invalid age %q", text)
+			}
+			return time.Duration(value) * unit, nil
+		}
+	}
+	age, err := time.ParseDuration(text)
+	if err != nil || age <= 0 {
+		return 0, fmt.Errorf("invalid age %q", text)
+	}
+	return age, nil
+}
+
+type dateGroup struct {
+	label string
+	files []FileEntry
+}
+
+// bucketFiles splits files by modification time, keeping their order within
+// each bucket and omitting empty buckets.
+func bucketFiles(files []FileEntry, buckets []DateBucket) []dateGroup {
+	groups := make([]dateGroup, len(buckets)+1)
+	for idx, bucket := range buckets {
+		groups[idx].label = bucket.Label
+	}
+	groups[len(buckets)].label = olderBucket
+
+	for _, file := range files {
+		idx := len(buckets)
+		for b, bucket := range buckets {
+			if !file.ModTime.Before(bucket.Since) {
+				idx = b
+				break
+			}
+		}
+		groups[idx].files = append(groups[idx].files, file)
+	}
+
+	nonEmpty := groups[:0]
+	for _, group := range groups {
+		if len(group.files) > 0 {
+			nonEmpty = append(nonEmpty, group)
+		}
+	}
+	return nonEmpty
+}
@@ -6,6 +6,9 @@ import "fmt"
 type DirGroup struct {
 	Signature string
 	Members   []*Directory
+	// Similar is set by GroupSimilar when the members are only alike, not
+	// identical; Signature is then that of the first member.
+	Similar bool
 }
 
 // GroupIdentical partitions directories into groups of identical structures while preserving
@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+)
+
+// The guarantee behind --deterministic: with a concurrent walk and parallel
+// exec commands, repeated runs over the same tree produce identical bytes.
+func TestRepeatedRunsAreIdentical(t *testing.T) {
+	root := writeSyntheticTree(t, 3, 4, 4)
+	opts := Options{MaxFiles: 2, Concurrency: 8}
+
+	first := ""
+	for run := 0; run < 100; run++ {
+		dir, err := Walk(root, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := render(t, dir, PrinterOptions{MaxDirs: 2})
+		if run == 0 {
+			first = out
+			continue
+		}
+		if out != first {
+			t.Fatalf("run %d rendered differently:\n%s\nfirst run:\n%s", run, out, first)
+		}
+	}
+}
+
+func TestOrderedExecIsIdentical(t *testing.T) {
+	if _, err := exec.LookPath("echo"); err != nil {
+		t.Skip("echo is not available")
+	}
+	paths := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+
+	var first []byte
+	for run := 0; run < 100; run++ {
+		var out bytes.Buffer
+		if _, err := RunExec("echo {}", paths, len(paths), true, &out); err != nil {
+			t.Fatal(err)
+		}
+		if run == 0 {
+			first = out.Bytes()
+			continue
+		}
+		if !bytes.Equal(out.Bytes(), first) {
+			t.Fatalf("run %d wrote %q, first run wrote %q", run, out.Bytes(), first)
+		}
+	}
+}
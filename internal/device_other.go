@@ -0,0 +1,11 @@
+//go:build !unix
+
+package internal
+
+import "io/fs"
+
+// deviceID is not supported on this platform, so --one-filesystem never
+// detects a mount boundary.
+func deviceID(info fs.FileInfo) (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// DumpDirectory writes every field of dir and its descendants in an indented
+// "Field: value" form, one directory per block, for debugging the walker.
+// Fields are listed by reflection so new ones show up automatically. The
+// layout is not stable and must not be parsed.
+func DumpDirectory(w io.Writer, dir *Directory) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# tree-pro internal dump (debug output, format may change)")
+	dumpDirectory(bw, dir, 0)
+	return bw.Flush()
+}
+
+func dumpDirectory(w io.Writer, dir *Directory, depth int) {
+	indent := strings.Repeat("  ", depth)
+	fmt.Fprintf(w, "%s%s/\n", indent, dir.Name)
+
+	value := reflect.ValueOf(dir).Elem()
+	fields := value.Type()
+	for idx := 0; idx < fields.NumField(); idx++ {
+		field := fields.Field(idx)
+		switch field.Name {
+		case "Name", "Subdirs":
+			continue
+		case "Files":
+			fmt.Fprintf(w, "%s  Files: %d\n", indent, len(dir.Files))
+			for _, file := range dir.Files {
+				fmt.Fprintf(w, "%s    %+v\n", indent, file)
+			}
+			continue
+		}
+		fmt.Fprintf(w, "%s  %s: %v\n", indent, field.Name, value.Field(idx).Interface())
+	}
+
+	fmt.Fprintf(w, "%s  Subdirs: %d\n", indent, len(dir.Subdirs))
+	for _, child := range dir.Subdirs {
+		dumpDirectory(w, child, depth+1)
+	}
+}
@@ -0,0 +1,26 @@
+package internal
+
+import "math"
+
+// ExtensionEntropy returns the Shannon entropy, in bits, of the distribution
+// of file extensions in counts. A directory holding a single file type scores
+// 0; one holding n types in equal numbers scores log2(n). Empty maps score 0.
+func ExtensionEntropy(counts map[string]int) float64 {
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+
+	entropy := 0.0
+	for _, count := range counts {
+		if count == 0 {
+			continue
+		}
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
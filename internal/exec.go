@@ -0,0 +1,195 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ExecFailure records a command that could not be run or exited non-zero.
+type ExecFailure struct {
+	Path string
+	Err  error
+}
+
+// ExecResult summarizes a RunExec invocation.
+type ExecResult struct {
+	Runs     int
+	Failures []ExecFailure
+}
+
+// RunExec runs command once per path, like find -exec. The command is split
+// into arguments like a shell would, honoring single and double quotes and
+// backslash escapes, and "{}" in any argument is replaced by the path; if no
+// argument contains "{}", the path is appended as the final argument. Up to
+// parallel commands run at once (values below 1 mean serial execution). The
+// combined output of each command is written to out as a single block, so
+// output from concurrent runs never interleaves. Blocks appear as commands
+// finish unless ordered is set, in which case they are written in path order
+// whatever the scheduling. Failures are returned in path order.
+func RunExec(command string, paths []string, parallel int, ordered bool, out io.Writer) (ExecResult, error) {
+	template, err := splitCommand(command)
+	if err != nil {
+		return ExecResult{}, err
+	}
+	if len(template) == 0 {
+		return ExecResult{}, fmt.Errorf("empty exec command")
+	}
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	errs := make([]error, len(paths))
+	var outputs [][]byte
+	if ordered {
+		outputs = make([][]byte, len(paths))
+	}
+	var outMu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, parallel)
+
+	for idx, path := range paths {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(idx int, path string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			args := expandExecArgs(template, path)
+			output, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+			errs[idx] = err
+			if ordered {
+				outputs[idx] = output
+				return
+			}
+
+			outMu.Lock()
+			out.Write(output)
+			outMu.Unlock()
+		}(idx, path)
+	}
+	wg.Wait()
+	for _, output := range outputs {
+		out.Write(output)
+	}
+
+	result := ExecResult{Runs: len(paths)}
+	for idx, err := range errs {
+		if err != nil {
+			result.Failures = append(result.Failures, ExecFailure{Path: paths[idx], Err: err})
+		}
+	}
+	return result, nil
+}
+
+// splitCommand splits command into arguments the way a POSIX shell splits
+// words: whitespace separates arguments, single quotes keep everything
+// literally, and inside double quotes a backslash only escapes '"', '\\', '$'
+// and '`'. Nothing else, such as variables or globs, is expanded.
+func splitCommand(command string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inWord := false
+	for idx := 0; idx < len(command); idx++ {
+		c := command[idx]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				args = append(args, current.String())
+				current.Reset()
+				inWord = false
+			}
+		case c == '\'':
+			end := strings.IndexByte(command[idx+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote in exec command")
+			}
+			current.WriteString(command[idx+1 : idx+1+end])
+			idx += end + 1
+			inWord = true
+		case c == '"':
+			idx++
+			for ; idx < len(command) && command[idx] != '"'; idx++ {
+				if command[idx] == '\\' && idx+1 < len(command) && strings.IndexByte("\"\\$`", command[idx+1]) >= 0 {
+					idx++
+				}
+				current.WriteByte(command[idx])
+			}
+			if idx >= len(command) {
+				return nil, fmt.Errorf("unterminated double quote in exec command")
+			}
+			inWord = true
+		case c == '\\':
+			if idx+1 < len(command) {
+				idx++
+				current.WriteByte(command[idx])
+			}
+			inWord = true
+		default:
+			current.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// ExecPaths returns the paths of the regular files listed in dir, in tree
+// order: each directory's subdirectories before its files. With pathTo set,
+// only files whose name matches it are returned, as the --path-to tree shows
+// only those. Files hidden by MaxFiles truncation are not in the tree, so
+// walk with MaxFiles 0 to get them all.
+func ExecPaths(dir *Directory, pathTo string) []string {
+	var focus *pathFocus
+	if pathTo != "" {
+		focus = &pathFocus{pattern: pathTo}
+	}
+	var paths []string
+	var visit func(dir *Directory)
+	visit = func(dir *Directory) {
+		for _, child := range dir.Subdirs {
+			if !child.Ghost {
+				visit(child)
+			}
+		}
+		for _, file := range dir.Files {
+			if file.Ghost || file.Special != "" || (focus != nil && !focus.matchName(file.Name)) {
+				continue
+			}
+			paths = append(paths, filepath.Join(dir.Path, file.Name))
+		}
+	}
+	visit(dir)
+	return paths
+}
+
+func expandExecArgs(template []string, path string) []string {
+	args := make([]string, 0, len(template)+1)
+	substituted := false
+	for _, arg := range template {
+		if strings.Contains(arg, "{}") {
+			arg = strings.ReplaceAll(arg, "{}", path)
+			substituted = true
+		}
+		args = append(args, arg)
+	}
+	if !substituted {
+		args = append(args, path)
+	}
+	return args
+}
+
+// PrintExecFooter writes a summary of result, listing each failed command.
+func PrintExecFooter(w io.Writer, result ExecResult, useColor bool) {
+	withColor(useColor, func(palette palette) {
+		fmt.Fprintln(w, palette.stats.Sprintf("[exec: %d commands, %d failed]", result.Runs, len(result.Failures)))
+		for _, failure := range result.Failures {
+			fmt.Fprintf(w, "%s %s\n", palette.file.Sprintf("%s", failure.Path), palette.err.Sprintf("[%s]", failure.Err))
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"gofmt -l {}", []string{"gofmt", "-l", "{}"}},
+		{`grep -n 'TODO: fix' {}`, []string{"grep", "-n", "TODO: fix", "{}"}},
+		{`echo "a \"b\" \$c" d\ e`, []string{"echo", `a "b" $c`, "d e"}},
+		{`echo '' x`, []string{"echo", "", "x"}},
+		{"  spaced   out  ", []string{"spaced", "out"}},
+	}
+	for _, tt := range tests {
+		got, err := splitCommand(tt.command)
+		if err != nil {
+			t.Errorf("splitCommand(%q): %v", tt.command, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("splitCommand(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+
+	for _, command := range []string{`echo 'open`, `echo "open`} {
+		if _, err := splitCommand(command); err == nil {
+			t.Errorf("splitCommand(%q) accepted an unterminated quote", command)
+		}
+	}
+}
+
+func TestExecPathsFollowsPrunedTree(t *testing.T) {
+	root := writeTree(t, "src/main.go", "src/util.go", "docs/guide.md")
+	dir, err := Walk(root, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pruned := PruneToPaths(dir, []string{"src/util.go", "docs/guide.md"})
+
+	got := ExecPaths(pruned, "")
+	want := []string{filepath.Join(root, "docs", "guide.md"), filepath.Join(root, "src", "util.go")}
+	if !slices.Equal(got, want) {
+		t.Errorf("ExecPaths = %q, want %q", got, want)
+	}
+	if got := ExecPaths(pruned, "*.go"); !slices.Equal(got, want[1:]) {
+		t.Errorf("ExecPaths with a path-to pattern = %q, want %q", got, want[1:])
+	}
+}
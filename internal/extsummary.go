@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// ExtStat describes how many files in a tree share a file extension.
+type ExtStat struct {
+	// Ext is the lowercased extension used as the grouping key.
+	Ext string
+	// Label is the text displayed for the group. It equals Ext unless the
+	// summary was built with preserveCase, in which case it is the most common
+	// original spelling (ties are broken by byte order, so ".JPG" wins over
+	// ".jpg" when both appear equally often).
+	Label string
+	Count int
+}
+
+// SummarizeExtensions aggregates the extension counts of dir and all of its
+// descendants. Grouping is always case-insensitive: ".JPG" and ".jpg" count
+// toward the same entry. Results are ordered by descending count, then by Ext.
+func SummarizeExtensions(dir *Directory, preserveCase bool) []ExtStat {
+	counts := make(map[string]int)
+	spellings := make(map[string]map[string]int)
+	collectExtensions(dir, counts, spellings)
+
+	stats := make([]ExtStat, 0, len(counts))
+	for ext, count := range counts {
+		label := ext
+		if preserveCase {
+			label = dominantSpelling(spellings[ext], ext)
+		}
+		stats = append(stats, ExtStat{Ext: ext, Label: label, Count: count})
+	}
+	sortExtStats(stats)
+	return stats
+}
+
+// SummarizeChangedExtensions counts slash-separated paths, such as those
+// returned by GitChangedFiles, per lowercased extension, ordered like
+// SummarizeExtensions.
+func SummarizeChangedExtensions(paths []string) []ExtStat {
+	counts := make(map[string]int)
+	for _, p := range paths {
+		ext := strings.ToLower(path.Ext(path.Base(p)))
+		if ext == "" {
+			ext = "<noext>"
+		}
+		counts[ext]++
+	}
+	stats := make([]ExtStat, 0, len(counts))
+	for ext, count := range counts {
+		stats = append(stats, ExtStat{Ext: ext, Label: ext, Count: count})
+	}
+	sortExtStats(stats)
+	return stats
+}
+
+func sortExtStats(stats []ExtStat) {
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return stats[i].Ext < stats[j].Ext
+	})
+}
+
+func collectExtensions(dir *Directory, counts map[string]int, spellings map[string]map[string]int) {
+	if dir == nil {
+		return
+	}
+	for ext, count := range dir.ExtCounts {
+		counts[ext] += count
+	}
+	for ext, variants := range dir.ExtSpellings {
+		if spellings[ext] == nil {
+			spellings[ext] = make(map[string]int)
+		}
+		for variant, count := range variants {
+			spellings[ext][variant] += count
+		}
+	}
+	for _, child := range dir.Subdirs {
+		collectExtensions(child, counts, spellings)
+	}
+}
+
+func dominantSpelling(variants map[string]int, fallback string) string {
+	best := fallback
+	bestCount := 0
+	for variant, count := range variants {
+		if count > bestCount || (count == bestCount && variant < best) {
+			best = variant
+			bestCount = count
+		}
+	}
+	return best
+}
@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSummarizeExtensionsMixedCase(t *testing.T) {
+	root := writeTree(t,
+		"a/one.JPG", "a/two.JPG", "a/three.jpg",
+		"b/four.png", "b/five.PNG",
+		"c/notes.Md",
+	)
+	dir, err := Walk(root, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		preserveCase bool
+		want         []ExtStat
+	}{
+		{false, []ExtStat{
+			{Ext: ".jpg", Label: ".jpg", Count: 3},
+			{Ext: ".png", Label: ".png", Count: 2},
+			{Ext: ".md", Label: ".md", Count: 1},
+		}},
+		// Grouping stays case-insensitive; the label is the most common
+		// spelling, and ties go to the spelling that sorts first.
+		{true, []ExtStat{
+			{Ext: ".jpg", Label: ".JPG", Count: 3},
+			{Ext: ".png", Label: ".PNG", Count: 2},
+			{Ext: ".md", Label: ".Md", Count: 1},
+		}},
+	}
+	for _, tt := range tests {
+		if got := SummarizeExtensions(dir, tt.preserveCase); !slices.Equal(got, tt.want) {
+			t.Errorf("SummarizeExtensions(preserveCase=%t) = %+v, want %+v", tt.preserveCase, got, tt.want)
+		}
+	}
+}
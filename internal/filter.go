@@ -0,0 +1,24 @@
+package internal
+
+import "path/filepath"
+
+// matchesAny reports whether name matches at least one of the glob patterns,
+// using filepath.Match semantics. Malformed patterns never match.
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, err := filepath.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// keepFile decides whether a file survives the walk filters. Exclude
+// patterns win over include patterns.
+func keepFile(name string, opts Options) bool {
+	if len(opts.IncludePatterns) > 0 && !matchesAny(opts.IncludePatterns, name) {
+		return false
+	}
+	excluded := matchesAny(opts.ExcludePatterns, name)
+	return excluded == opts.ShowOnlyExcluded
+}
@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// GitChangedFiles lists the files under dir that differ between the git ref
+// and the working tree, as slash-separated paths relative to dir. It runs
+// "git diff --name-only --relative ref", so untracked files are not included.
+func GitChangedFiles(dir, ref string) ([]string, error) {
+	// Outside a repository git diff silently switches to --no-index mode,
+	// so check for a work tree first.
+	if _, err := runGit(dir, "rev-parse", "--is-inside-work-tree"); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, fmt.Errorf("git is not installed")
+		}
+		return nil, fmt.Errorf("%s is not inside a git repository", dir)
+	}
+	output, err := runGit(dir, "diff", "--name-only", "--relative", ref, "--")
+	if err != nil {
+		return nil, fmt.Errorf("git diff %s: %w", ref, err)
+	}
+	var paths []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			paths = append(paths, line)
+		}
+	}
+	return paths, nil
+}
+
+// runGit runs git in dir and returns its standard output. Failures carry the
+// trimmed standard error.
+func runGit(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, errors.New(message)
+		}
+		return nil, err
+	}
+	return output, nil
+}
+
+// PruneToPaths returns a copy of root keeping only the files whose
+// slash-separated path relative to root is listed, plus the directories that
+// lead to them. Counts, sizes and extension tallies are recomputed for the
+// pruned tree, and every kept
+// directory gets a signature unique to its path so no two of them collapse
+// as identical. It returns nil when no listed file is in the tree.
+func PruneToPaths(root *Directory, paths []string) *Directory {
+	keep := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		keep[p] = true
+	}
+	return pruneToPaths(root, ".", keep)
+}
+
+func pruneToPaths(dir *Directory, rel string, keep map[string]bool) *Directory {
+	pruned := *dir
+	pruned.Files = nil
+	pruned.Subdirs = nil
+	pruned.HiddenFiles = 0
+	pruned.TotalDirs = 0
+	pruned.TotalSize = 0
+	pruned.TotalSymlinks = 0
+	pruned.TotalErrors = 0
+	pruned.ExtCounts = map[string]int{}
+	pruned.ExtSpellings = map[string]map[string]int{}
+	pruned.ExtSizes = map[string]int64{}
+	for _, file := range dir.Files {
+		if !keep[filepath.ToSlash(filepath.Join(rel, file.Name))] {
+			continue
+		}
+		pruned.Files = append(pruned.Files, file)
+		pruned.TotalSize += file.Size
+		// FileEntry does not record whether it was a link, so ask again.
+		if info, err := os.Lstat(filepath.Join(dir.Path, file.Name)); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+			pruned.TotalSymlinks++
+		}
+		original := filepath.Ext(file.Name)
+		if original == "" {
+			original = "<noext>"
+		}
+		ext := strings.ToLower(original)
+		pruned.ExtCounts[ext]++
+		if pruned.ExtSpellings[ext] == nil {
+			pruned.ExtSpellings[ext] = map[string]int{}
+		}
+		pruned.ExtSpellings[ext][original]++
+		pruned.ExtSizes[ext] += file.Size
+	}
+	pruned.TotalFiles = len(pruned.Files)
+	for _, child := range dir.Subdirs {
+		if kept := pruneToPaths(child, filepath.Join(rel, child.Name), keep); kept != nil {
+			pruned.Subdirs = append(pruned.Subdirs, kept)
+			pruned.TotalDirs += kept.TotalDirs + 1
+			pruned.TotalFiles += kept.TotalFiles
+			pruned.TotalSize += kept.TotalSize
+			pruned.TotalSymlinks += kept.TotalSymlinks
+			pruned.TotalErrors += kept.TotalErrors
+		}
+	}
+	if pruned.TotalFiles == 0 {
+		return nil
+	}
+	pruned.ImmediateDirCount = len(pruned.Subdirs)
+	pruned.ImmediateFileCount = len(pruned.Files)
+	pruned.Signature = signatureForLeaf(dir.Path)
+	return &pruned
+}
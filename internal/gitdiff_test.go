@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPruneToPathsRecomputesTotals(t *testing.T) {
+	root := t.TempDir()
+	sizes := map[string]int{
+		"keep.go":       10,
+		"drop.go":       100,
+		"a/keep.md":     20,
+		"a/drop.md":     200,
+		"a/b/keep.go":   30,
+		"dropped/x.txt": 400,
+	}
+	for name, size := range sizes {
+		full := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("keep.go", filepath.Join(root, "a", "link.go")); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	link, err := os.Lstat(filepath.Join(root, "a", "link.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := Walk(root, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pruned := PruneToPaths(dir, []string{"keep.go", "a/keep.md", "a/link.go", "a/b/keep.go"})
+	if pruned == nil {
+		t.Fatal("PruneToPaths returned nil")
+	}
+	if got, want := pruned.TotalSize, 10+20+30+link.Size(); got != want {
+		t.Errorf("TotalSize = %d, want %d", got, want)
+	}
+	if pruned.TotalFiles != 4 || pruned.TotalDirs != 2 {
+		t.Errorf("TotalFiles, TotalDirs = %d, %d, want 4, 2", pruned.TotalFiles, pruned.TotalDirs)
+	}
+	if pruned.TotalSymlinks != 1 {
+		t.Errorf("TotalSymlinks = %d, want 1", pruned.TotalSymlinks)
+	}
+	if got := pruned.ExtCounts; len(got) != 1 || got[".go"] != 1 {
+		t.Errorf("root ExtCounts = %v, want map[.go:1]", got)
+	}
+	a := pruned.Subdirs[0]
+	if a.Name != "a" {
+		t.Fatalf("first kept subdirectory = %q, want a", a.Name)
+	}
+	if a.ExtSizes[".md"] != 20 || a.ExtCounts[".md"] != 1 {
+		t.Errorf("a: .md count %d size %d, want 1 and 20", a.ExtCounts[".md"], a.ExtSizes[".md"])
+	}
+	if got, want := a.TotalSize, 20+30+link.Size(); got != want {
+		t.Errorf("a: TotalSize = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,128 @@
+package internal
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRule is one pattern line of a .gitignore file. base is the path of
+// the directory holding the file; anchored patterns, those with a slash
+// before their last character, match paths relative to it, the others match
+// base names at any depth below it.
+type ignoreRule struct {
+	base     string
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// loadGitignore returns inherited extended by the rules of the .gitignore in
+// dirPath. A missing or unreadable file adds nothing. inherited is never
+// modified, so sibling directories do not see each other's rules.
+func loadGitignore(dirPath string, inherited []ignoreRule) []ignoreRule {
+	f, err := os.Open(filepath.Join(dirPath, ".gitignore"))
+	if err != nil {
+		return inherited
+	}
+	defer f.Close()
+
+	rules := inherited[:len(inherited):len(inherited)]
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreLine(dirPath, scanner.Text()); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+func parseIgnoreLine(base, line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, "\r")
+	// Trailing spaces are ignored unless escaped.
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+	rule.pattern = line
+	return rule, true
+}
+
+// gitignored reports whether the entry at entryPath is ignored by rules. The
+// last matching rule decides, so a later negation re-includes an entry an
+// earlier pattern ignored.
+func gitignored(rules []ignoreRule, entryPath string, isDir bool) bool {
+	ignored := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		rel, err := filepath.Rel(rule.base, entryPath)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		var matched bool
+		if rule.anchored {
+			matched = matchIgnorePath(strings.Split(rule.pattern, "/"), strings.Split(rel, "/"))
+		} else {
+			matched, _ = path.Match(rule.pattern, path.Base(rel))
+		}
+		if matched {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// matchIgnorePath matches a slash-separated pattern against a relative path
+// segment by segment; a "**" segment matches any number of segments.
+func matchIgnorePath(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			if len(pattern) == 1 {
+				// A trailing "**" matches everything inside, not the
+				// directory itself.
+				return len(segments) > 0
+			}
+			for skip := 0; skip <= len(segments); skip++ {
+				if matchIgnorePath(pattern[1:], segments[skip:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"regexp"
+)
+
+// defaultGrepMaxSize caps the size of files searched for ContentPattern when
+// Options.ContentMaxSize is not set.
+const defaultGrepMaxSize = 1 << 20
+
+// countMatchingLines returns how many lines of the file at path pattern
+// matches. Files larger than limit, binary files (holding a NUL byte) and
+// unreadable files are not searched and count zero.
+func countMatchingLines(path string, pattern *regexp.Regexp, limit int64) int {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil || int64(len(data)) > limit || bytes.IndexByte(data, 0) >= 0 {
+		return 0
+	}
+
+	count := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		if pattern.Match(scanner.Bytes()) {
+			count++
+		}
+	}
+	return count
+}
+
+// PruneEmptyDirs returns a copy of root without the directories below it
+// that hold no files at any depth, such as those left empty by
+// Options.ContentPattern. Directory counts are recomputed; unread and
+// unreadable directories are kept so they stay visible.
+func PruneEmptyDirs(root *Directory) *Directory {
+	pruned := *root
+	pruned.Subdirs = nil
+	pruned.TotalDirs = 0
+	for _, child := range root.Subdirs {
+		if child.TotalFiles == 0 && child.Err == nil && !child.Leaf && !child.Unexpanded {
+			continue
+		}
+		kept := PruneEmptyDirs(child)
+		pruned.Subdirs = append(pruned.Subdirs, kept)
+		pruned.TotalDirs += kept.TotalDirs + 1
+	}
+	pruned.ImmediateDirCount = len(pruned.Subdirs)
+	return &pruned
+}
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+)
+
+// heatmapMax returns the largest TotalFiles of any directory strictly below
+// root, the top of the Heatmap gradient.
+func heatmapMax(root *Directory) int {
+	most := 0
+	for _, child := range root.Subdirs {
+		most = max(most, child.TotalFiles, heatmapMax(child))
+	}
+	return most
+}
+
+// heatmapEnabled reports whether Heatmap backgrounds should be emitted.
+func heatmapEnabled(opts PrinterOptions) bool {
+	return opts.Heatmap && opts.UseColor
+}
+
+// heatmapLabel paints text with a truecolor background for count on a
+// logarithmic blue (few files) to red (most files) gradient, choosing black
+// or white text by the background's luminance so it stays readable.
+func heatmapLabel(text string, count, most int) string {
+	ratio := 0.0
+	if most > 0 && count > 0 {
+		ratio = math.Log1p(float64(count)) / math.Log1p(float64(most))
+	}
+	r, g, b := hueToRGB(240 * (1 - min(ratio, 1)))
+	fg := 255
+	if 0.299*float64(r)+0.587*float64(g)+0.114*float64(b) > 150 {
+		fg = 0
+	}
+	return fmt.Sprintf("\x1b[48;2;%d;%d;%d;38;2;%d;%d;%dm%s\x1b[0m", r, g, b, fg, fg, fg, text)
+}
+
+// hueToRGB converts a hue in degrees to an RGB color at 75% saturation and
+// 50% lightness.
+func hueToRGB(hue float64) (int, int, int) {
+	const saturation, lightness = 0.75, 0.5
+	chroma := (1 - math.Abs(2*lightness-1)) * saturation
+	x := chroma * (1 - math.Abs(math.Mod(hue/60, 2)-1))
+	var r, g, b float64
+	switch {
+	case hue < 60:
+		r, g = chroma, x
+	case hue < 120:
+		r, g = x, chroma
+	case hue < 180:
+		g, b = chroma, x
+	case hue < 240:
+		g, b = x, chroma
+	default:
+		r, b = x, chroma
+	}
+	m := lightness - chroma/2
+	return int(math.Round((r + m) * 255)), int(math.Round((g + m) * 255)), int(math.Round((b + m) * 255))
+}
@@ -0,0 +1,43 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTree creates the slash-separated paths below a new temporary
+// directory and returns it. Paths ending in '/' become directories, the
+// others empty files.
+func writeTree(t testing.TB, paths ...string) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, path := range paths {
+		full := filepath.Join(root, filepath.FromSlash(path))
+		if strings.HasSuffix(path, "/") {
+			if err := os.MkdirAll(full, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// render prints dir with opts into a string.
+func render(t testing.TB, dir *Directory, opts PrinterOptions) string {
+	t.Helper()
+	var out strings.Builder
+	opts.Writer = &out
+	if err := PrintTree("root/", dir, opts); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// renderUL writes the tree as nested <ul>/<li> elements. Every item carries a
+// class hook (tp-dir, tp-file, tp-error, tp-summary) so callers can style the
+// tree with their own CSS. Ordering and collapsing match the text renderer.
+func renderUL(w io.Writer, rootLabel string, dir *Directory, opts PrinterOptions) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, `<ul class="tp-tree">`)
+	writeULDir(bw, dir, rootLabel, 1, opts)
+	fmt.Fprintln(bw, `</ul>`)
+	return bw.Flush()
+}
+
+func writeULDir(w io.Writer, dir *Directory, label string, depth int, opts PrinterOptions) {
+	indent := strings.Repeat("  ", depth)
+	if dir.Err != nil {
+		fmt.Fprintf(
+			w,
+			"%s<li class=\"tp-dir tp-error\">%s <span class=\"tp-error\">[%s]</span></li>\n",
+			indent,
+			html.EscapeString(label),
+			html.EscapeString(errorText(dir)),
+		)
+		return
+	}
+
+	items := buildItems(dir, opts)
+	if len(items) == 0 {
+		fmt.Fprintf(w, "%s<li class=\"tp-dir\">%s</li>\n", indent, html.EscapeString(label))
+		return
+	}
+
+	fmt.Fprintf(w, "%s<li class=\"tp-dir\">%s\n", indent, html.EscapeString(label))
+	fmt.Fprintf(w, "%s  <ul>\n", indent)
+	for _, item := range items {
+		switch item.kind {
+		case itemDir:
+			writeULDir(w, item.dir, item.dir.Name+"/", depth+2, opts)
+		case itemFile:
+			fmt.Fprintf(w, "%s    <li class=\"tp-file\">%s</li>\n", indent, html.EscapeString(item.file.Name))
+		default:
+			fmt.Fprintf(w, "%s    <li class=\"tp-summary\">%s</li>\n", indent, html.EscapeString(itemSummary(dir, item)))
+		}
+	}
+	fmt.Fprintf(w, "%s  </ul>\n", indent)
+	fmt.Fprintf(w, "%s</li>\n", indent)
+}
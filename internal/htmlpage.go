@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"os"
+)
+
+// HTMLTree is one root rendered by PrintHTMLPage.
+type HTMLTree struct {
+	Label string
+	Dir   *Directory
+}
+
+// PrintHTMLPage writes a self-contained HTML page with one tab per tree. Each
+// tab header shows the root label with its directory and file counts, and
+// each panel holds the tree as the nested list of FormatUL. Tabs switch with
+// CSS radio buttons, so the page needs no script or external assets.
+func PrintHTMLPage(trees []HTMLTree, opts PrinterOptions) error {
+	writer := opts.Writer
+	if writer == nil {
+		writer = os.Stdout
+	}
+	bw := bufio.NewWriter(writer)
+
+	fmt.Fprintln(bw, "<!DOCTYPE html>")
+	fmt.Fprintln(bw, `<html lang="en">`)
+	fmt.Fprintln(bw, "<head>")
+	fmt.Fprintln(bw, `<meta charset="utf-8">`)
+	fmt.Fprintln(bw, "<title>tree-pro</title>")
+	fmt.Fprintln(bw, "<style>")
+	fmt.Fprintln(bw, "body { font-family: ui-monospace, monospace; margin: 1.5em; }")
+	fmt.Fprintln(bw, ".tp-tabs > input { display: none; }")
+	fmt.Fprintln(bw, ".tp-tabs > label { display: inline-block; padding: 0.4em 0.9em; border: 1px solid #d1d5db; border-bottom: none; cursor: pointer; background: #f3f4f6; }")
+	fmt.Fprintln(bw, ".tp-tabs > input:checked + label { background: #ffffff; font-weight: bold; }")
+	fmt.Fprintln(bw, ".tp-panel { display: none; border: 1px solid #d1d5db; padding: 0.5em 1em; }")
+	for idx := range trees {
+		fmt.Fprintf(bw, "#tp-tab-%d:checked ~ #tp-panel-%d { display: block; }\n", idx, idx)
+	}
+	fmt.Fprintln(bw, ".tp-stats { color: #6b7280; font-weight: normal; }")
+	fmt.Fprintln(bw, ".tp-tree, .tp-tree ul { list-style: none; padding-left: 1.2em; }")
+	fmt.Fprintln(bw, ".tp-dir { color: #1d4ed8; }")
+	fmt.Fprintln(bw, ".tp-file { color: #111827; }")
+	fmt.Fprintln(bw, ".tp-error { color: #b91c1c; }")
+	fmt.Fprintln(bw, ".tp-summary { color: #6b7280; font-style: italic; }")
+	fmt.Fprintln(bw, "</style>")
+	fmt.Fprintln(bw, "</head>")
+	fmt.Fprintln(bw, "<body>")
+	fmt.Fprintln(bw, `<div class="tp-tabs">`)
+
+	for idx, tree := range trees {
+		checked := ""
+		if idx == 0 {
+			checked = " checked"
+		}
+		fmt.Fprintf(bw, "<input type=\"radio\" name=\"tp-tab\" id=\"tp-tab-%d\"%s>\n", idx, checked)
+		fmt.Fprintf(
+			bw,
+			"<label for=\"tp-tab-%d\">%s <span class=\"tp-stats\">[%d directories, %d files]</span></label>\n",
+			idx,
+			html.EscapeString(tree.Label),
+			tree.Dir.TotalDirs+1,
+			tree.Dir.TotalFiles,
+		)
+	}
+	for idx, tree := range trees {
+		label, dir, treeOpts, err := prepareTree(tree.Label, tree.Dir, opts)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(bw, "<div class=\"tp-panel\" id=\"tp-panel-%d\">\n", idx)
+		if err := renderUL(bw, label, dir, treeOpts); err != nil {
+			return err
+		}
+		fmt.Fprintln(bw, "</div>")
+	}
+
+	fmt.Fprintln(bw, "</div>")
+	fmt.Fprintln(bw, "</body>")
+	fmt.Fprintln(bw, "</html>")
+	return bw.Flush()
+}
@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// IgnoreSource is a group of exclude globs written by WriteIgnore under a
+// comment naming where they came from, such as "--exclude".
+type IgnoreSource struct {
+	Name     string
+	Patterns []string
+}
+
+// WriteIgnore writes the patterns of sources in .gitignore syntax, one
+// commented block per non-empty source. A slash-free gitignore pattern
+// matches base names at any depth, like an exclude glob; patterns starting
+// with '#' or '!' are escaped so git does not read them as comments or
+// negations.
+func WriteIgnore(w io.Writer, sources []IgnoreSource) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# Exclude patterns written by tree-pro --emit-ignore.")
+	fmt.Fprintln(bw, "# tree-pro applies them to files only; git also applies them to directories.")
+	for _, source := range sources {
+		if len(source.Patterns) == 0 {
+			continue
+		}
+		fmt.Fprintf(bw, "\n# from %s\n", source.Name)
+		for _, pattern := range source.Patterns {
+			if strings.HasPrefix(pattern, "#") || strings.HasPrefix(pattern, "!") {
+				pattern = `\` + pattern
+			}
+			fmt.Fprintln(bw, pattern)
+		}
+	}
+	return bw.Flush()
+}
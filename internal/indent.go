@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// defaultIndentWidth is the number of spaces per level used by FormatIndent
+// when PrinterOptions.IndentWidth is not set.
+const defaultIndentWidth = 2
+
+// renderIndent writes one line per entry, indented by IndentWidth spaces per
+// level and without any connector glyphs. Directories end in '/', and
+// collapse and summary lines are indented like the entries they stand for.
+func renderIndent(w io.Writer, rootLabel string, dir *Directory, opts PrinterOptions) error {
+	step := opts.IndentWidth
+	if step <= 0 {
+		step = defaultIndentWidth
+	}
+	bw := bufio.NewWriter(w)
+	depth := 0
+	if !opts.NoRoot {
+		fmt.Fprintln(bw, rootLabel)
+		depth = 1
+	}
+	writeIndentItems(bw, dir, strings.Repeat(" ", step), depth, opts)
+	return bw.Flush()
+}
+
+func writeIndentItems(w io.Writer, dir *Directory, unit string, depth int, opts PrinterOptions) {
+	indent := strings.Repeat(unit, depth)
+	for _, item := range buildItems(dir, opts) {
+		switch item.kind {
+		case itemDir:
+			if item.dir.Err != nil {
+				fmt.Fprintf(w, "%s%s/ [%s]\n", indent, item.dir.Name, errorText(item.dir))
+				continue
+			}
+			fmt.Fprintf(w, "%s%s/\n", indent, item.dir.Name)
+			writeIndentItems(w, item.dir, unit, depth+1, opts)
+		case itemFile:
+			fmt.Fprintf(w, "%s%s\n", indent, item.file.Name)
+		case itemDateGroup:
+			fmt.Fprintf(w, "%s%s\n", indent, itemSummary(dir, item))
+			for _, file := range item.dateGroup.files {
+				fmt.Fprintf(w, "%s%s%s\n", indent, unit, file.Name)
+			}
+		default:
+			fmt.Fprintf(w, "%s%s\n", indent, itemSummary(dir, item))
+		}
+	}
+}
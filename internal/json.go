@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+)
+
+type jsonNode struct {
+	Name               string     `json:"name"`
+	Path               string     `json:"path"`
+	Level              int        `json:"level"`
+	Files              []string   `json:"files"`
+	ImmediateDirCount  int        `json:"immediateDirCount"`
+	ImmediateFileCount int        `json:"immediateFileCount"`
+	TotalDirs          int        `json:"totalDirs"`
+	TotalFiles         int        `json:"totalFiles"`
+	Error              string     `json:"error,omitempty"`
+	CollapsedDirs      int        `json:"collapsedDirs,omitempty"`
+	HiddenFiles        int        `json:"hiddenFiles,omitempty"`
+	Children           []jsonNode `json:"children"`
+	// Balance is set on the root object only, for PrinterOptions.Balance.
+	Balance *BalanceStats `json:"balance,omitempty"`
+}
+
+// renderJSON writes the tree as nested JSON objects, one per directory, for
+// tools that would otherwise scrape the text tree. It lists the same entries
+// as the text renderer: identical directories folded by MaxDirs are counted
+// in collapsedDirs and files truncated by MaxFiles (or elided by PathTo) in
+// hiddenFiles. Levels are relative to the rendered root. Balance adds the
+// depth statistics of the whole tree to the root object.
+func renderJSON(w io.Writer, rootLabel string, dir *Directory, opts PrinterOptions) error {
+	opts.UseColor = false
+	node := jsonDir(dir, rootLabel, ".", opts)
+	if opts.Balance {
+		stats := TreeBalance(opts.whole)
+		node.Balance = &stats
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(node)
+}
+
+func jsonDir(dir *Directory, name, rel string, opts PrinterOptions) jsonNode {
+	node := jsonNode{
+		Name:               name,
+		Path:               filepath.ToSlash(rel),
+		Level:              dir.Level - opts.root.Level,
+		Files:              []string{},
+		ImmediateDirCount:  dir.ImmediateDirCount,
+		ImmediateFileCount: dir.ImmediateFileCount,
+		TotalDirs:          dir.TotalDirs,
+		TotalFiles:         dir.TotalFiles,
+		Children:           []jsonNode{},
+	}
+	if dir.Err != nil {
+		node.Error = errorText(dir)
+		return node
+	}
+	for _, item := range buildItems(dir, opts) {
+		switch item.kind {
+		case itemDir:
+			childRel := filepath.Join(rel, item.dir.Name)
+			node.Children = append(node.Children, jsonDir(item.dir, item.dir.Name, childRel, opts))
+		case itemFile:
+			node.Files = append(node.Files, item.file.Name)
+		case itemDateGroup:
+			for _, file := range item.dateGroup.files {
+				node.Files = append(node.Files, file.Name)
+			}
+		case itemCollapse:
+			node.CollapsedDirs += item.collapseCount
+		case itemNumbered:
+			node.CollapsedDirs += len(item.numbered.Members)
+		case itemFileSummary, itemElided:
+			node.HiddenFiles += item.collapseCount
+		}
+	}
+	return node
+}
@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// LabelData is the value LabelTemplate and FileLabelTemplate are executed
+// with. For files, TotalDirs and TotalFiles are zero and Size is the file's
+// own size; for directories, Size is the recursive TotalSize.
+type LabelData struct {
+	Name       string
+	Path       string
+	Level      int
+	IsDir      bool
+	TotalDirs  int
+	TotalFiles int
+	Size       int64
+	HumanSize  string
+	ModTime    time.Time
+}
+
+// ParseLabelTemplate parses a label template and executes it once against an
+// empty LabelData, so unknown fields are reported before any output.
+func ParseLabelTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(io.Discard, LabelData{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// dirLabel returns the text shown for a directory: its name, its full path
+// with FullPath, the output of LabelTemplate, or with Shape only its file
+// count.
+func dirLabel(dir *Directory, opts PrinterOptions) string {
+	if opts.Shape {
+		return shapeDirLabel(dir)
+	}
+	if opts.LabelTemplate != nil {
+		return executeLabel(opts.LabelTemplate, LabelData{
+			Name:       dir.Name,
+			Path:       dir.Path,
+			Level:      dir.Level,
+			IsDir:      true,
+			TotalDirs:  dir.TotalDirs,
+			TotalFiles: dir.TotalFiles,
+			Size:       dir.TotalSize,
+			HumanSize:  FormatSize(dir.TotalSize),
+			ModTime:    dir.ModTime,
+		}, dir.Name)
+	}
+	if opts.FullPath {
+		return displayPath(dir.Path, opts)
+	}
+	return dir.Name
+}
+
+// fileLabel is dirLabel for the files of dir; relDir is used by PathsInTree.
+func fileLabel(dir *Directory, file FileEntry, relDir string, opts PrinterOptions) string {
+	path := filepath.Join(dir.Path, file.Name)
+	switch {
+	case opts.Shape:
+		return shapeFile
+	case opts.FileLabelTemplate != nil:
+		return executeLabel(opts.FileLabelTemplate, LabelData{
+			Name:      file.Name,
+			Path:      path,
+			Level:     dir.Level + 1,
+			Size:      file.Size,
+			HumanSize: FormatSize(file.Size),
+			ModTime:   file.ModTime,
+		}, file.Name)
+	case opts.FullPath:
+		return displayPath(path, opts)
+	case opts.PathsInTree:
+		return filepath.Join(relDir, file.Name)
+	}
+	return file.Name
+}
+
+// executeLabel runs tmpl, falling back to name if it fails part way.
+func executeLabel(tmpl *template.Template, data LabelData, name string) string {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return name
+	}
+	return b.String()
+}
@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// languageByExt maps lowercased file extensions to language names.
+var languageByExt = map[string]string{
+	".c":     "C",
+	".h":     "C",
+	".cc":    "C++",
+	".cpp":   "C++",
+	".cxx":   "C++",
+	".hpp":   "C++",
+	".cs":    "C#",
+	".clj":   "Clojure",
+	".css":   "CSS",
+	".dart":  "Dart",
+	".ex":    "Elixir",
+	".exs":   "Elixir",
+	".erl":   "Erlang",
+	".go":    "Go",
+	".hs":    "Haskell",
+	".html":  "HTML",
+	".htm":   "HTML",
+	".java":  "Java",
+	".js":    "JavaScript",
+	".mjs":   "JavaScript",
+	".cjs":   "JavaScript",
+	".jsx":   "JavaScript",
+	".json":  "JSON",
+	".kt":    "Kotlin",
+	".kts":   "Kotlin",
+	".lua":   "Lua",
+	".md":    "Markdown",
+	".m":     "Objective-C",
+	".mm":    "Objective-C++",
+	".php":   "PHP",
+	".pl":    "Perl",
+	".proto": "Protocol Buffers",
+	".py":    "Python",
+	".r":     "R",
+	".rb":    "Ruby",
+	".rs":    "Rust",
+	".scala": "Scala",
+	".scss":  "SCSS",
+	".sh":    "Shell",
+	".bash":  "Shell",
+	".zsh":   "Shell",
+	".sql":   "SQL",
+	".swift": "Swift",
+	".tf":    "HCL",
+	".toml":  "TOML",
+	".ts":    "TypeScript",
+	".tsx":   "TypeScript",
+	".vue":   "Vue",
+	".xml":   "XML",
+	".yaml":  "YAML",
+	".yml":   "YAML",
+	".zig":   "Zig",
+}
+
+// LanguageFor returns the language of a file name based on its extension, or
+// "" when the extension is not recognized.
+func LanguageFor(name string) string {
+	return languageByExt[strings.ToLower(filepath.Ext(name))]
+}
+
+// LangStat is one entry of a language breakdown.
+type LangStat struct {
+	Language string
+	Files    int
+	Bytes    int64
+	// Percent is the language's share of all recognized files, measured by
+	// file count or by bytes depending on how the breakdown was built.
+	Percent float64
+}
+
+// SummarizeLanguages aggregates the files below dir by language, like
+// GitHub's language bar. Files with unrecognized extensions are left out.
+// Shares are computed from bytes when bySize is set and from file counts
+// otherwise; results are ordered by descending share, then by name.
+func SummarizeLanguages(dir *Directory, bySize bool) []LangStat {
+	counts := make(map[string]int)
+	sizes := make(map[string]int64)
+	collectExtSizes(dir, counts, sizes)
+
+	byLang := make(map[string]*LangStat)
+	var total float64
+	for ext, count := range counts {
+		lang, ok := languageByExt[ext]
+		if !ok {
+			continue
+		}
+		stat := byLang[lang]
+		if stat == nil {
+			stat = &LangStat{Language: lang}
+			byLang[lang] = stat
+		}
+		stat.Files += count
+		stat.Bytes += sizes[ext]
+		if bySize {
+			total += float64(sizes[ext])
+		} else {
+			total += float64(count)
+		}
+	}
+
+	stats := make([]LangStat, 0, len(byLang))
+	for _, stat := range byLang {
+		if total > 0 {
+			share := float64(stat.Files)
+			if bySize {
+				share = float64(stat.Bytes)
+			}
+			stat.Percent = share / total * 100
+		}
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Percent != stats[j].Percent {
+			return stats[i].Percent > stats[j].Percent
+		}
+		return stats[i].Language < stats[j].Language
+	})
+	return stats
+}
+
+func collectExtSizes(dir *Directory, counts map[string]int, sizes map[string]int64) {
+	if dir == nil {
+		return
+	}
+	for ext, count := range dir.ExtCounts {
+		counts[ext] += count
+	}
+	for ext, size := range dir.ExtSizes {
+		sizes[ext] += size
+	}
+	for _, child := range dir.Subdirs {
+		collectExtSizes(child, counts, sizes)
+	}
+}
@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// lineNumberDigits is the minimum width of the --numbered column; larger
+// numbers simply widen it.
+const lineNumberDigits = 4
+
+// lineNumberMode selects what a lineNumberWriter puts in front of a line.
+type lineNumberMode int
+
+const (
+	// linesPlain writes lines untouched.
+	linesPlain lineNumberMode = iota
+	// linesNumbered prefixes each line with the next number.
+	linesNumbered
+	// linesPadded indents lines by the width of the number column so they stay
+	// aligned with numbered ones.
+	linesPadded
+)
+
+// lineNumberWriter prefixes lines written through it with a running counter.
+// The counter is shared by everything written in linesNumbered mode, so
+// numbers stay sequential across recursion and summary lines. A nil writer
+// ignores mode changes.
+type lineNumberWriter struct {
+	w         io.Writer
+	mode      lineNumberMode
+	next      int
+	midLine   bool
+	padString string
+}
+
+func newLineNumberWriter(w io.Writer) *lineNumberWriter {
+	return &lineNumberWriter{
+		w:         w,
+		next:      1,
+		padString: strings.Repeat(" ", lineNumberDigits+2),
+	}
+}
+
+func (l *lineNumberWriter) setMode(mode lineNumberMode) {
+	if l != nil {
+		l.mode = mode
+	}
+}
+
+func (l *lineNumberWriter) Write(p []byte) (int, error) {
+	rest := p
+	for len(rest) > 0 {
+		if !l.midLine {
+			if err := l.writePrefix(); err != nil {
+				return len(p) - len(rest), err
+			}
+		}
+		end := len(rest)
+		l.midLine = true
+		if idx := bytes.IndexByte(rest, '\n'); idx >= 0 {
+			end = idx + 1
+			l.midLine = false
+		}
+		if _, err := l.w.Write(rest[:end]); err != nil {
+			return len(p) - len(rest), err
+		}
+		rest = rest[end:]
+	}
+	return len(p), nil
+}
+
+func (l *lineNumberWriter) writePrefix() error {
+	var err error
+	switch l.mode {
+	case linesNumbered:
+		_, err = fmt.Fprintf(l.w, "%*d  ", lineNumberDigits, l.next)
+		l.next++
+	case linesPadded:
+		_, err = io.WriteString(l.w, l.padString)
+	}
+	return err
+}
@@ -0,0 +1,24 @@
+package internal
+
+import "regexp"
+
+// CountNameViolations reports how many files listed below dir have a name
+// that pattern does not match. Like SummarizeNames, it only sees files the
+// walk recorded, so MaxFiles truncation can hide violations.
+func CountNameViolations(dir *Directory, pattern *regexp.Regexp) int {
+	count := 0
+	for _, file := range dir.Files {
+		if !file.Ghost && !pattern.MatchString(file.Name) {
+			count++
+		}
+	}
+	for _, child := range dir.Subdirs {
+		count += CountNameViolations(child, pattern)
+	}
+	return count
+}
+
+// violatesNaming reports whether file should be marked by LintNames.
+func violatesNaming(file FileEntry, opts PrinterOptions) bool {
+	return opts.LintNames != nil && !file.Ghost && !opts.LintNames.MatchString(file.Name)
+}
@@ -0,0 +1,226 @@
+package internal
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ReadPathList reads one slash-separated relative path per line. Blank lines
+// and lines starting with '#' are ignored; a trailing '/' marks a directory.
+func ReadPathList(r io.Reader) ([]string, error) {
+	var paths []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	return paths, scanner.Err()
+}
+
+// ReadPathListFile is ReadPathList for a named file.
+func ReadPathListFile(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadPathList(f)
+}
+
+// BuildFromPaths assembles a Directory tree from slash-separated relative
+// paths without touching the filesystem. Paths ending in '/' become
+// directories; every other path becomes a file, and missing parent
+// directories are created implicitly. Entries are sorted by name and counts
+// and signatures are filled in as the walker would.
+func BuildFromPaths(rootName string, paths []string) *Directory {
+	root := &Directory{Name: rootName, Path: "."}
+	for _, p := range paths {
+		isDir := strings.HasSuffix(p, "/")
+		clean := path.Clean(strings.TrimPrefix(p, "/"))
+		if clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+			continue
+		}
+		parts := strings.Split(clean, "/")
+		dir := root
+		last := len(parts) - 1
+		if isDir {
+			last = len(parts)
+		}
+		for _, part := range parts[:last] {
+			dir = childDir(dir, part)
+		}
+		if !isDir {
+			addFileOnce(dir, parts[len(parts)-1])
+		}
+	}
+	finishBuiltDir(root)
+	return root
+}
+
+func childDir(dir *Directory, name string) *Directory {
+	for _, child := range dir.Subdirs {
+		if child.Name == name {
+			return child
+		}
+	}
+	child := &Directory{
+		Name:  name,
+		Path:  path.Join(dir.Path, name),
+		Level: dir.Level + 1,
+	}
+	dir.Subdirs = append(dir.Subdirs, child)
+	return child
+}
+
+func addFileOnce(dir *Directory, name string) {
+	for _, file := range dir.Files {
+		if file.Name == name {
+			return
+		}
+	}
+	dir.Files = append(dir.Files, FileEntry{Name: name})
+}
+
+func finishBuiltDir(dir *Directory) {
+	sort.Slice(dir.Subdirs, func(i, j int) bool { return dir.Subdirs[i].Name < dir.Subdirs[j].Name })
+	sort.Slice(dir.Files, func(i, j int) bool { return dir.Files[i].Name < dir.Files[j].Name })
+
+	dir.ExtCounts = map[string]int{}
+	for _, file := range dir.Files {
+		ext := strings.ToLower(path.Ext(file.Name))
+		if ext == "" {
+			ext = "<noext>"
+		}
+		dir.ExtCounts[ext]++
+	}
+
+	dir.ImmediateDirCount = len(dir.Subdirs)
+	dir.ImmediateFileCount = len(dir.Files)
+	dir.TotalDirs = len(dir.Subdirs)
+	dir.TotalFiles = len(dir.Files)
+	for _, child := range dir.Subdirs {
+		finishBuiltDir(child)
+		dir.TotalDirs += child.TotalDirs
+		dir.TotalFiles += child.TotalFiles
+	}
+	dir.Signature = signatureForDirectory(dir.ExtCounts, dir.Subdirs)
+}
+
+// MarkExpected merges the expected tree into actual by name. Entries present
+// in both are flagged Expected; expected entries missing from actual are
+// added as Ghost entries. Ghosts do not change actual's counts. An entry
+// missing from actual only because the walk filtered it out still exists on
+// disk, so existence is checked there and no ghost is added for it. It
+// returns the slash-separated relative paths of every missing entry, in tree
+// order.
+func MarkExpected(actual, expected *Directory) []string {
+	var missing []string
+	markExpected(actual, expected, "", &missing)
+	return missing
+}
+
+func markExpected(actual, expected *Directory, rel string, missing *[]string) {
+	addedFiles := false
+	for _, want := range expected.Files {
+		idx := fileIndex(actual.Files, want.Name)
+		if idx >= 0 {
+			actual.Files[idx].Expected = true
+			continue
+		}
+		if existsOnDisk(actual.Path, want.Name) {
+			continue
+		}
+		actual.Files = append(actual.Files, FileEntry{Name: want.Name, Ghost: true})
+		*missing = append(*missing, path.Join(rel, want.Name))
+		addedFiles = true
+	}
+	if addedFiles {
+		sort.SliceStable(actual.Files, func(i, j int) bool { return actual.Files[i].Name < actual.Files[j].Name })
+	}
+
+	for _, want := range expected.Subdirs {
+		childRel := path.Join(rel, want.Name)
+		if child := subdirNamed(actual, want.Name); child != nil {
+			child.Expected = true
+			// The contents of unread directories are unknown, so
+			// nothing below them is reported missing.
+			if child.Err == nil && !child.Leaf && !child.Unexpanded {
+				markExpected(child, want, childRel, missing)
+			}
+			continue
+		}
+		if existsOnDisk(actual.Path, want.Name) {
+			// Filtered out of the tree: there is nowhere to show its
+			// contents, but those that do not exist are still missing.
+			missingOnDisk(filepath.Join(actual.Path, want.Name), want, childRel, missing)
+			continue
+		}
+		actual.Subdirs = append(actual.Subdirs, ghostTree(want, actual, childRel, missing))
+	}
+}
+
+// missingOnDisk appends the entries of want that do not exist below dirPath.
+func missingOnDisk(dirPath string, want *Directory, rel string, missing *[]string) {
+	for _, file := range want.Files {
+		if !existsOnDisk(dirPath, file.Name) {
+			*missing = append(*missing, path.Join(rel, file.Name))
+		}
+	}
+	for _, child := range want.Subdirs {
+		childRel := path.Join(rel, child.Name)
+		if !existsOnDisk(dirPath, child.Name) {
+			*missing = append(*missing, childRel+"/")
+		}
+		missingOnDisk(filepath.Join(dirPath, child.Name), child, childRel, missing)
+	}
+}
+
+func existsOnDisk(dirPath, name string) bool {
+	_, err := os.Lstat(filepath.Join(dirPath, name))
+	return err == nil
+}
+
+func ghostTree(want, parent *Directory, rel string, missing *[]string) *Directory {
+	*missing = append(*missing, rel+"/")
+	ghost := &Directory{
+		Name:      want.Name,
+		Path:      path.Join(parent.Path, want.Name),
+		Level:     parent.Level + 1,
+		Ghost:     true,
+		Signature: "ghost:" + want.Signature,
+	}
+	for _, file := range want.Files {
+		ghost.Files = append(ghost.Files, FileEntry{Name: file.Name, Ghost: true})
+		*missing = append(*missing, path.Join(rel, file.Name))
+	}
+	for _, child := range want.Subdirs {
+		ghost.Subdirs = append(ghost.Subdirs, ghostTree(child, ghost, path.Join(rel, child.Name), missing))
+	}
+	return ghost
+}
+
+func fileIndex(files []FileEntry, name string) int {
+	for idx, file := range files {
+		if file.Name == name {
+			return idx
+		}
+	}
+	return -1
+}
+
+func subdirNamed(dir *Directory, name string) *Directory {
+	for _, child := range dir.Subdirs {
+		if child.Name == name {
+			return child
+		}
+	}
+	return nil
+}
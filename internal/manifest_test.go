@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMarkExpectedHiddenEntries(t *testing.T) {
+	root := writeTree(t, ".env", ".cfg/x", "a/r.go", "a/.hid/y")
+	dir, err := Walk(root, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := BuildFromPaths(dir.Name, []string{".env", ".cfg/x", ".cfg/z", "a/r.go", "a/.hid/y", "a/.hid/q", "nope/f"})
+
+	missing := MarkExpected(dir, expected)
+	if want := []string{".cfg/z", "a/.hid/q", "nope/", "nope/f"}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("missing = %v, want %v", missing, want)
+	}
+	// Dotfiles are looked up, not listed: the walk's visibility is kept.
+	for _, child := range dir.Subdirs {
+		if child.Name == ".cfg" {
+			t.Error("hidden directory .cfg was added to the tree")
+		}
+	}
+	if len(dir.Files) != 0 {
+		t.Errorf("root files = %v, want none", dir.Files)
+	}
+}
+
+func TestMarkExpectedFilteredFiles(t *testing.T) {
+	root := writeTree(t, "a/r.go", "a/s.txt", "a/t.txt", "a/u.txt", "b/v.go")
+	dir, err := Walk(root, Options{ExcludePatterns: []string{"*.go"}, MaxFiles: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := BuildFromPaths(dir.Name, []string{"a/r.go", "a/s.txt", "a/u.txt", "a/w.txt", "b/v.go"})
+
+	if missing, want := MarkExpected(dir, expected), []string{"a/w.txt"}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("missing = %v, want %v", missing, want)
+	}
+	var ghosts []string
+	for _, file := range dir.Subdirs[0].Files {
+		if file.Ghost {
+			ghosts = append(ghosts, file.Name)
+		}
+	}
+	if want := []string{"w.txt"}; !reflect.DeepEqual(ghosts, want) {
+		t.Errorf("ghost files in a = %v, want %v", ghosts, want)
+	}
+}
@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMaxDepthAppliesToEveryFormat(t *testing.T) {
+	root := writeTree(t, "top/middle/bottom/leaf.txt", "top/shallow.txt")
+	dir, err := Walk(root, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []string{FormatTree, FormatIndent, FormatUL, FormatMermaid, FormatURLs, FormatD3, FormatSVG, FormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			out := render(t, dir, PrinterOptions{Format: format, MaxDepth: 2, URLDirs: true})
+			for _, want := range []string{"top", "middle", "shallow.txt"} {
+				if !strings.Contains(out, want) {
+					t.Errorf("output lacks %q within the depth limit:\n%s", want, out)
+				}
+			}
+			for _, unwanted := range []string{"bottom", "leaf.txt"} {
+				if strings.Contains(out, unwanted) {
+					t.Errorf("output shows %q below the depth limit:\n%s", unwanted, out)
+				}
+			}
+		})
+	}
+}
+
+// MaxDepth (--max-components) only limits the display, while MaxLevel (-L)
+// stops the walk, so only the former still counts what it hides.
+func TestMaxDepthKeepsCountsUnlikeMaxLevel(t *testing.T) {
+	root := writeTree(t, "top/middle/bottom/leaf.txt", "top/shallow.txt")
+
+	full, err := Walk(root, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := render(t, full, PrinterOptions{MaxDepth: 2})
+	if !strings.Contains(out, "[4 directories, 2 files]") {
+		t.Errorf("MaxDepth report should count the whole tree:\n%s", out)
+	}
+
+	walked, err := Walk(root, Options{MaxLevel: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if walked.TotalFiles != 1 {
+		t.Errorf("MaxLevel walk counted %d files, want 1", walked.TotalFiles)
+	}
+	out = render(t, walked, PrinterOptions{})
+	if strings.Contains(out, "leaf.txt") {
+		t.Errorf("MaxLevel walk shows an entry it never read:\n%s", out)
+	}
+}
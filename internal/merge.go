@@ -0,0 +1,80 @@
+package internal
+
+import "path/filepath"
+
+// MergeRoot is one walked tree handed to MergeRoots, together with the path
+// it was walked from.
+type MergeRoot struct {
+	Path string
+	Dir  *Directory
+}
+
+// MergeRoots assembles several walked trees as the subdirectories of a new
+// synthetic directory named label, whose totals cover every root. Each root is
+// named after the base of its absolute path; roots whose names collide are
+// prefixed with their parent directory, and with the full parent path when
+// that is not enough. The roots are modified in place: their names change and
+// their levels move one step down.
+func MergeRoots(label string, roots []MergeRoot) *Directory {
+	merged := &Directory{
+		Name:      label,
+		ExtCounts: make(map[string]int),
+	}
+
+	names := mergedNames(roots)
+	for idx, root := range roots {
+		dir := root.Dir
+		dir.Name = names[idx]
+		shiftLevels(dir, 1)
+		merged.Subdirs = append(merged.Subdirs, dir)
+		merged.TotalDirs += dir.TotalDirs + 1
+		merged.TotalFiles += dir.TotalFiles
+		merged.TotalSize += dir.TotalSize
+		merged.TotalSymlinks += dir.TotalSymlinks
+		merged.TotalErrors += dir.TotalErrors
+		if dir.ModTime.After(merged.ModTime) {
+			merged.ModTime = dir.ModTime
+		}
+	}
+	merged.ImmediateDirCount = len(merged.Subdirs)
+	merged.Signature = signatureForDirectory(merged.ExtCounts, merged.Subdirs)
+	return merged
+}
+
+func mergedNames(roots []MergeRoot) []string {
+	abs := make([]string, len(roots))
+	names := make([]string, len(roots))
+	for idx, root := range roots {
+		abs[idx] = root.Path
+		if p, err := filepath.Abs(root.Path); err == nil {
+			abs[idx] = p
+		}
+		names[idx] = filepath.Base(abs[idx])
+	}
+
+	disambiguate := func(rename func(idx int) string) {
+		seen := make(map[string]int, len(names))
+		for _, name := range names {
+			seen[name]++
+		}
+		for idx, name := range names {
+			if seen[name] > 1 {
+				names[idx] = rename(idx)
+			}
+		}
+	}
+	disambiguate(func(idx int) string {
+		return filepath.Join(filepath.Base(filepath.Dir(abs[idx])), names[idx])
+	})
+	disambiguate(func(idx int) string {
+		return abs[idx]
+	})
+	return names
+}
+
+func shiftLevels(dir *Directory, delta int) {
+	dir.Level += delta
+	for _, child := range dir.Subdirs {
+		shiftLevels(child, delta)
+	}
+}
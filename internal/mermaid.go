@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// renderMermaid writes the tree as a Mermaid "graph TD" diagram. Node ids are
+// hashes of paths relative to the root, and every node carries one of the
+// tpDir, tpFile, tpError or tpSummary classes, defined with default styles
+// that can be overridden by the embedding page.
+func renderMermaid(w io.Writer, rootLabel string, dir *Directory, opts PrinterOptions) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "graph TD")
+	writeMermaidDir(bw, dir, rootLabel, ".", opts)
+	fmt.Fprintln(bw, "  classDef tpDir fill:#dbeafe,stroke:#1d4ed8")
+	fmt.Fprintln(bw, "  classDef tpFile fill:#f9fafb,stroke:#9ca3af")
+	fmt.Fprintln(bw, "  classDef tpError fill:#fee2e2,stroke:#b91c1c")
+	fmt.Fprintln(bw, "  classDef tpSummary fill:#f3f4f6,stroke:#9ca3af,stroke-dasharray:3 3")
+	return bw.Flush()
+}
+
+func writeMermaidDir(w io.Writer, dir *Directory, label, rel string, opts PrinterOptions) string {
+	id := "n" + pathID(rel)
+	if dir.Err != nil {
+		writeMermaidNode(w, id, label+" ["+errorText(dir)+"]", "tpError")
+		return id
+	}
+	writeMermaidNode(w, id, label, "tpDir")
+
+	for idx, item := range buildItems(dir, opts) {
+		var childID string
+		switch item.kind {
+		case itemDir:
+			childID = writeMermaidDir(w, item.dir, item.dir.Name+"/", filepath.Join(rel, item.dir.Name), opts)
+		case itemFile:
+			childID = "n" + pathID(filepath.Join(rel, item.file.Name))
+			writeMermaidNode(w, childID, item.file.Name, "tpFile")
+		default:
+			childID = "n" + pathID(fmt.Sprintf("%s#summary:%d", rel, idx))
+			writeMermaidNode(w, childID, itemSummary(dir, item), "tpSummary")
+		}
+		fmt.Fprintf(w, "  %s --> %s\n", id, childID)
+	}
+	return id
+}
+
+func writeMermaidNode(w io.Writer, id, label, class string) {
+	fmt.Fprintf(w, "  %s[\"%s\"]:::%s\n", id, mermaidEscape(label), class)
+}
+
+// mermaidEscape replaces characters that would end or corrupt a quoted
+// Mermaid label with their entity codes.
+func mermaidEscape(label string) string {
+	return strings.NewReplacer(
+		`"`, "#quot;",
+		"<", "#lt;",
+		">", "#gt;",
+	).Replace(label)
+}
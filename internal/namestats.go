@@ -0,0 +1,45 @@
+package internal
+
+// NameStats summarizes the byte lengths of file names.
+type NameStats struct {
+	Files    int
+	Min, Max int
+	Mean     float64
+	// OverLimit counts names longer than the limit passed to
+	// SummarizeNames.
+	OverLimit int
+}
+
+// SummarizeNames computes NameStats over the files listed below dir. Files
+// hidden by MaxFiles truncation or sampling have no recorded name and are
+// not included.
+func SummarizeNames(dir *Directory, limit int) NameStats {
+	var stats NameStats
+	total := 0
+	var visit func(dir *Directory)
+	visit = func(dir *Directory) {
+		for _, file := range dir.Files {
+			if file.Ghost {
+				continue
+			}
+			length := len(file.Name)
+			if stats.Files == 0 || length < stats.Min {
+				stats.Min = length
+			}
+			stats.Max = max(stats.Max, length)
+			total += length
+			stats.Files++
+			if limit > 0 && length > limit {
+				stats.OverLimit++
+			}
+		}
+		for _, child := range dir.Subdirs {
+			visit(child)
+		}
+	}
+	visit(dir)
+	if stats.Files > 0 {
+		stats.Mean = float64(total) / float64(stats.Files)
+	}
+	return stats
+}
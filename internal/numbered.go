@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// numberedName splits a name into the text before and after its last run of
+// digits, e.g. "run_12.out" into "run_", "12" and ".out".
+var numberedName = regexp.MustCompile(`^(.*?)(\d+)(\D*)$`)
+
+// NumberedGroup is a set of sibling directories whose names differ only in a
+// number, such as run_1, run_2 and run_3.
+type NumberedGroup struct {
+	// Label renders the shared template with the numbers as compact ranges,
+	// e.g. "run_{1..3,7}".
+	Label   string
+	Members []*Directory
+}
+
+type numberedMember struct {
+	dir    *Directory
+	digits string
+	value  int
+}
+
+// GroupNumbered partitions dirs into groups of at least two directories that
+// share a prefix + digits + suffix name template, regardless of their
+// contents. Directories that do not join such a group are returned in rest.
+// Groups and rest keep the original order of appearance.
+func GroupNumbered(dirs []*Directory) (groups []NumberedGroup, rest []*Directory) {
+	byTemplate := make(map[string][]numberedMember)
+	templates := make(map[string][2]string)
+	order := make([]string, 0)
+
+	for _, dir := range dirs {
+		m := numberedName.FindStringSubmatch(dir.Name)
+		if m == nil {
+			continue
+		}
+		value, err := strconv.Atoi(m[2])
+		if err != nil {
+			continue
+		}
+		key := m[1] + "\x00" + m[3]
+		if _, seen := byTemplate[key]; !seen {
+			order = append(order, key)
+			templates[key] = [2]string{m[1], m[3]}
+		}
+		byTemplate[key] = append(byTemplate[key], numberedMember{dir: dir, digits: m[2], value: value})
+	}
+
+	grouped := make(map[*Directory]bool)
+	for _, key := range order {
+		members := byTemplate[key]
+		if len(members) < 2 {
+			continue
+		}
+		group := NumberedGroup{
+			Label: templates[key][0] + "{" + numberRanges(members) + "}" + templates[key][1],
+		}
+		for _, member := range members {
+			group.Members = append(group.Members, member.dir)
+			grouped[member.dir] = true
+		}
+		groups = append(groups, group)
+	}
+
+	for _, dir := range dirs {
+		if !grouped[dir] {
+			rest = append(rest, dir)
+		}
+	}
+	return groups, rest
+}
+
+// numberRanges formats the member numbers in ascending order, folding
+// consecutive values into "a..b" ranges.
+func numberRanges(members []numberedMember) string {
+	sorted := append([]numberedMember(nil), members...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].value < sorted[j].value })
+
+	var parts []string
+	for start := 0; start < len(sorted); {
+		end := start
+		for end+1 < len(sorted) && sorted[end+1].value == sorted[end].value+1 {
+			end++
+		}
+		if end == start {
+			parts = append(parts, sorted[start].digits)
+		} else {
+			parts = append(parts, fmt.Sprintf("%s..%s", sorted[start].digits, sorted[end].digits))
+		}
+		start = end + 1
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// DefaultPackageManifests lists the file names that mark a package root when
+// Options.PackageManifests is not configured otherwise.
+var DefaultPackageManifests = []string{"package.json", "go.mod", "Cargo.toml", "pyproject.toml"}
+
+// maxManifestSize caps how much of a manifest is read for its name and
+// version.
+const maxManifestSize = 1 << 20
+
+// PackageInfo describes the package whose manifest a directory holds. Name
+// and Version are empty when the manifest does not declare them or its
+// format is not one readPackage understands.
+type PackageInfo struct {
+	Manifest string
+	Name     string
+	Version  string
+}
+
+// Label returns "name@version", the bare name when there is no version, or
+// the manifest file name when there is no name either.
+func (p *PackageInfo) Label() string {
+	switch {
+	case p.Name == "":
+		return p.Manifest
+	case p.Version == "":
+		return p.Name
+	}
+	return p.Name + "@" + p.Version
+}
+
+// preferManifest reports whether filename should replace current as the
+// manifest of a directory: manifests listed earlier in manifests win.
+func preferManifest(current *PackageInfo, filename string, manifests []string) bool {
+	idx := slices.Index(manifests, filename)
+	if idx < 0 {
+		return false
+	}
+	return current == nil || idx < slices.Index(manifests, current.Manifest)
+}
+
+// readPackage parses the manifest at path. Unreadable or malformed manifests
+// still mark the package, just without a name.
+func readPackage(path, manifest string) *PackageInfo {
+	info := &PackageInfo{Manifest: manifest}
+	f, err := os.Open(path)
+	if err != nil {
+		return info
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxManifestSize))
+	if err != nil {
+		return info
+	}
+
+	switch manifest {
+	case "package.json":
+		var pkg struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		}
+		if json.Unmarshal(data, &pkg) == nil {
+			info.Name, info.Version = pkg.Name, pkg.Version
+		}
+	case "go.mod":
+		info.Name = goModulePath(data)
+	case "Cargo.toml":
+		info.Name, info.Version = tomlNameVersion(data, "package")
+	case "pyproject.toml":
+		info.Name, info.Version = tomlNameVersion(data, "project", "tool.poetry")
+	}
+	return info
+}
+
+func goModulePath(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
+// tomlNameVersion reads the name and version keys of the first of sections
+// that declares a name. It understands only the flat key = "value" lines
+// manifests use for these keys, not TOML in general.
+func tomlNameVersion(data []byte, sections ...string) (name, version string) {
+	values := make(map[string]map[string]string)
+	current := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			current = strings.TrimSpace(strings.Trim(line, "[]"))
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || !slices.Contains(sections, current) {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if len(value) < 2 || (value[0] != '"' && value[0] != '\'') || value[len(value)-1] != value[0] {
+			continue
+		}
+		if values[current] == nil {
+			values[current] = make(map[string]string)
+		}
+		values[current][key] = value[1 : len(value)-1]
+	}
+	for _, section := range sections {
+		if values[section]["name"] != "" {
+			return values[section]["name"], values[section]["version"]
+		}
+	}
+	return "", ""
+}
+
+// isPackageCollapsed reports whether dir is rendered as a single package line
+// by PrinterOptions.Packages.
+func isPackageCollapsed(dir *Directory, opts PrinterOptions) bool {
+	return opts.Packages && !opts.ExpandPackages && dir.Package != nil && dir != opts.root
+}
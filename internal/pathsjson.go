@@ -0,0 +1,33 @@
+package internal
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// renderPathsJSON writes a JSON array of every listed file's slash-separated
+// path relative to the root, sorted bytewise so the output does not depend on
+// walk order. Files dropped by MaxFiles truncation are not listed, which is
+// why the command line walks with unlimited --files for this format.
+func renderPathsJSON(w io.Writer, dir *Directory) error {
+	paths := []string{}
+	collectFilePaths(dir, "", &paths)
+	sort.Strings(paths)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(paths)
+}
+
+func collectFilePaths(dir *Directory, rel string, paths *[]string) {
+	for _, file := range dir.Files {
+		if !file.Ghost {
+			*paths = append(*paths, joinSlash(rel, file.Name))
+		}
+	}
+	for _, child := range dir.Subdirs {
+		if !child.Ghost {
+			collectFilePaths(child, joinSlash(rel, child.Name), paths)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package internal
+
+import "path/filepath"
+
+// pathFocus records which directories lie on the way to entries matching a
+// --path-to pattern.
+type pathFocus struct {
+	pattern string
+	onPath  map[*Directory]bool
+	matches int
+}
+
+// CountPathMatches reports how many files and directories below dir have a
+// name matching pattern (filepath.Match syntax; a plain name matches itself).
+func CountPathMatches(dir *Directory, pattern string) int {
+	return newPathFocus(dir, pattern).matches
+}
+
+func newPathFocus(root *Directory, pattern string) *pathFocus {
+	focus := &pathFocus{
+		pattern: pattern,
+		onPath:  make(map[*Directory]bool),
+	}
+	focus.mark(root)
+	return focus
+}
+
+// mark walks dir and reports whether it contains a match, recording every
+// directory that does.
+func (f *pathFocus) mark(dir *Directory) bool {
+	found := false
+	for _, file := range dir.Files {
+		if f.matchName(file.Name) {
+			f.matches++
+			found = true
+		}
+	}
+	for _, child := range dir.Subdirs {
+		if f.matchName(child.Name) {
+			f.matches++
+			f.onPath[child] = true
+			found = true
+		}
+		if f.mark(child) {
+			f.onPath[child] = true
+			found = true
+		}
+	}
+	return found
+}
+
+func (f *pathFocus) matchName(name string) bool {
+	if name == f.pattern {
+		return true
+	}
+	ok, err := filepath.Match(f.pattern, name)
+	return err == nil && ok
+}
+
+// buildFocusedItems keeps only the directories leading to a match and the
+// matching files, folding everything else into a single elision marker.
+// With hideFiles the matching files are folded too.
+func buildFocusedItems(dir *Directory, focus *pathFocus, hideFiles bool) []treeItem {
+	items := make([]treeItem, 0)
+	elided := 0
+
+	for _, child := range dir.Subdirs {
+		if focus.onPath[child] {
+			items = append(items, treeItem{kind: itemDir, dir: child})
+		} else {
+			elided++
+		}
+	}
+	for _, file := range dir.Files {
+		if !hideFiles && focus.matchName(file.Name) {
+			items = append(items, treeItem{kind: itemFile, file: file})
+		} else {
+			elided++
+		}
+	}
+
+	elided += dir.HiddenFiles
+	if elided > 0 {
+		items = append(items, treeItem{kind: itemElided, collapseCount: elided})
+	}
+	return items
+}
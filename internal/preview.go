@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultPreviewMaxSize caps the size of files read for PreviewLines when
+// Options.PreviewMaxSize is not set.
+const defaultPreviewMaxSize = 64 * 1024
+
+// readPreview returns the first n lines of a text file and whether it has
+// more. Files that look binary (a NUL byte or invalid UTF-8) or cannot be
+// read yield no lines. Tabs become spaces and other control characters are
+// dropped so the lines cannot disturb the tree.
+func readPreview(path string, n int, limit int64) ([]string, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, limit))
+	if err != nil || bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+		return nil, false
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if len(lines) == n {
+			return lines, true
+		}
+		lines = append(lines, sanitizePreviewLine(scanner.Text()))
+	}
+	return lines, false
+}
+
+func sanitizePreviewLine(line string) string {
+	line = strings.ReplaceAll(line, "\t", "    ")
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, line)
+}
@@ -1,20 +1,254 @@
 package internal
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 	"math"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/fatih/color"
 )
 
+// Supported values for PrinterOptions.Format.
+const (
+	FormatTree    = "tree"
+	FormatUL      = "ul"
+	FormatD3      = "d3"
+	FormatURLs    = "urls"
+	FormatMermaid = "mermaid"
+	FormatIndent  = "indent"
+	FormatSVG     = "svg"
+	FormatHTML    = "html"
+	FormatPaths   = "paths-json"
+	FormatJSON    = "json"
+)
+
+// Supported values for PrinterOptions.Charset.
+const (
+	CharsetUnicode = "unicode"
+	CharsetRounded = "rounded"
+	CharsetASCII   = "ascii"
+)
+
+// connectorSet holds the glyphs used to draw tree branches. The connector
+// fields are four columns wide so prefixes stay aligned across depths;
+// barFull and barEmpty are the single-column cells of --bars.
+type connectorSet struct {
+	branch   string
+	last     string
+	pipe     string
+	blank    string
+	barFull  string
+	barEmpty string
+}
+
+var connectorSets = map[string]connectorSet{
+	CharsetUnicode: {branch: "├── ", last: "└── ", pipe: "│   ", blank: "    ", barFull: "█", barEmpty: "░"},
+	CharsetRounded: {branch: "├── ", last: "╰── ", pipe: "│   ", blank: "    ", barFull: "█", barEmpty: "░"},
+	CharsetASCII:   {branch: "|-- ", last: "`-- ", pipe: "|   ", blank: "    ", barFull: "#", barEmpty: "."},
+}
+
 // PrinterOptions controls how the tree is rendered.
 type PrinterOptions struct {
 	Writer   io.Writer
 	MaxDirs  int
 	UseColor bool
+	// ExtSummary appends a per-extension file count after the stats line.
+	ExtSummary bool
+	// PreserveExtCase displays the most common original spelling of each
+	// extension in the summary instead of its lowercased key.
+	PreserveExtCase bool
+	// ExtChurn, when not nil, is printed after the tree as the number of
+	// files changed per extension since the git ref ExtChurnRef.
+	ExtChurn    []ExtStat
+	ExtChurnRef string
+	// PathTo, when set, renders only the branches leading to entries whose
+	// name matches this pattern, highlighting them and eliding the rest.
+	PathTo string
+	// ShowEntropy appends the Shannon entropy of each directory's immediate
+	// file extensions to its label.
+	ShowEntropy bool
+	// PathsInTree labels file lines with their path relative to the root
+	// instead of their base name. Directory lines keep their base names.
+	PathsInTree bool
+	// Format selects the output format: FormatTree (the default when empty)
+	// FormatUL, FormatD3, FormatURLs, FormatMermaid, FormatIndent,
+	// FormatSVG, FormatHTML, FormatPaths or FormatJSON.
+	Format string
+	// Charset selects the connector glyphs: CharsetUnicode (the default when
+	// empty), CharsetRounded or CharsetASCII, for viewers that mangle
+	// box-drawing characters.
+	Charset string
+	// ReadmeCheck marks each directory with whether it contains a README and
+	// lists the directories missing one after the stats line.
+	ReadmeCheck bool
+	// ShowLanguages appends each file's language to its line and prints a
+	// language breakdown after the stats line, weighted by bytes when
+	// LanguagesBySize is set and by file count otherwise.
+	ShowLanguages   bool
+	LanguagesBySize bool
+	// NoRoot skips the root label and renders the root's entries at the
+	// leftmost column without connectors, for splicing into other documents.
+	NoRoot bool
+	// NoReport omits the "[N directories, M files]" stats line.
+	NoReport bool
+	// ShowFilteredCounts appends "(N shown, M filtered)" to directories
+	// whose entries were reduced by walk filters.
+	ShowFilteredCounts bool
+	// Width, when positive, truncates every line of the text tree to this
+	// many visible columns, ending shortened lines with "…".
+	Width int
+	// ExcludedReport prints how many files matched the exclude patterns, for
+	// trees walked with Options.ShowOnlyExcluded.
+	ExcludedReport bool
+	// FullPath labels every entry with its full path, as the walk saw it,
+	// instead of its base name. StripPrefix is removed from the start of
+	// such paths when present.
+	FullPath    bool
+	StripPrefix string
+	// URLBase is the base URL prepended to paths by FormatURLs. URLDirs also
+	// lists each directory's index URL.
+	URLBase string
+	URLDirs bool
+	// CollapseNumbered folds sibling directories whose names differ only by
+	// a number (run_1, run_2, ...) into one "run_{1..2}/" entry, whatever
+	// their contents. ExpandNumbered shows the first member's contents
+	// beneath it.
+	CollapseNumbered bool
+	ExpandNumbered   bool
+	// Totals prints a per-type breakdown of the tree's totals after the stats
+	// line. Categories listed in TotalsOmit ("directories", "files",
+	// "symlinks", "errors") are left out.
+	Totals     bool
+	TotalsOmit []string
+	// MaxDepth limits how many levels below the root are rendered, in every
+	// format but FormatPaths, without changing the walked tree (0 for
+	// unlimited).
+	MaxDepth int
+	// HideFiles renders directories only.
+	HideFiles bool
+	// DirTotals annotates each directory with its recursive counts.
+	DirTotals bool
+	// Overview prints a two-level, directories-only outline annotated with
+	// recursive counts before the full tree.
+	Overview bool
+	// Hyperlinks wraps entry names in OSC 8 escapes linking to their file://
+	// URL. Like colors, links are only emitted when UseColor is set.
+	Hyperlinks bool
+	// DateBuckets, when set, groups each directory's files under one
+	// sub-heading per bucket (plus "older") by modification time. Only the
+	// text tree renders the groups.
+	DateBuckets []DateBucket
+	// SectionChars, when positive, inserts a header before each run of
+	// entries whose names share their first SectionChars letters, folding
+	// case unless SectionCaseSensitive is set.
+	SectionChars         int
+	SectionCaseSensitive bool
+	// AnnotateJSON appends a " // {...}" comment holding the path, type,
+	// size and, for directories, recursive counts to every directory and
+	// file line of the text tree.
+	AnnotateJSON bool
+	// NumberLines prefixes every tree line, including collapse and summary
+	// lines, with a sequential line number. The root and stats lines are
+	// numbered too unless NumberEntriesOnly is set, in which case they are
+	// only indented to keep the columns aligned.
+	NumberLines       bool
+	NumberEntriesOnly bool
+	// Anchor, when set, renders only the subtree at this path relative to the
+	// root, under a breadcrumb showing where it sits. The stats line and the
+	// summaries after the tree still describe the whole walked root.
+	Anchor string
+	// DiskUsage appends each directory's recursive size and each file's size,
+	// and the size of the whole tree to the report line.
+	// DiskUsageSort additionally lists subdirectories and files largest first
+	// within every directory.
+	DiskUsage     bool
+	DiskUsageSort bool
+	// DirsFirst lists each directory's subdirectories ahead of its files.
+	// Without it the two are merged in the order SortBy and Reverse gave the
+	// walk (see Options), as if sorted together; SortNone and DateBuckets
+	// always keep directories first, as there is no common order to merge
+	// by.
+	DirsFirst bool
+	SortBy    string
+	Reverse   bool
+	// IndentWidth is the number of spaces per level for FormatIndent
+	// (2 when zero).
+	IndentWidth int
+	// FoldAt, when positive, renders any directory below the root holding
+	// more than this many files recursively as a single folded line in the
+	// text tree, whatever its depth.
+	FoldAt int
+	// Packages labels directories that hold a package manifest with the
+	// package name and version, and renders those below the root as a single
+	// line unless ExpandPackages is set.
+	Packages       bool
+	ExpandPackages bool
+	// SVGFont and SVGFontSize set the font of FormatSVG ("monospace" and 14
+	// when empty).
+	SVGFont     string
+	SVGFontSize int
+	// Heatmap paints the background of every directory label below the root
+	// on a truecolor gradient by its recursive file count, relative to the
+	// fullest directory. It needs UseColor.
+	Heatmap bool
+	// Balance prints depth statistics for the files of the tree after it.
+	Balance bool
+	// SizeBars, with DiskUsage, appends a bar to every directory showing its
+	// TotalSize relative to its largest sibling directory.
+	SizeBars bool
+	// NameStats prints the minimum, maximum and mean byte length of the
+	// listed file names after the tree, and marks files whose name is longer
+	// than NameLimit bytes.
+	NameStats bool
+	NameLimit int
+	// LintNames marks in the warning color every file whose name it does
+	// not match and counts the violations after the tree.
+	LintNames *regexp.Regexp
+	// Authors maps file paths, as built by the walker, to their last
+	// author (see GitFileAuthors). Listed files are annotated with it and
+	// directories with the author of most of the files below them.
+	Authors map[string]string
+	// LabelTemplate and FileLabelTemplate, when set, replace the name of
+	// each directory and file in the text tree with the template's output,
+	// executed with a LabelData. A directory template also replaces the
+	// trailing '/', so "{{.Name}}/" reproduces the default; annotations are
+	// always kept.
+	LabelTemplate     *template.Template
+	FileLabelTemplate *template.Template
+	// Similarity, when between 0 and 1, also collapses sibling directories
+	// whose structures are at least this similar (see GroupSimilar). Zero
+	// means 1: identical directories only.
+	Similarity float64
+	// Shape hides every name in the text tree: files are drawn as a dot and
+	// directories, the root included, as their recursive file count, so only
+	// the structure remains. File previews are left out.
+	Shape bool
+	// MatchCounts appends the number of lines Options.ContentPattern matched
+	// to every file.
+	MatchCounts bool
+	// WalkTimeout, when non-zero, reports under the stats line that the walk
+	// was stopped after this long and the tree is partial.
+	WalkTimeout time.Duration
+
+	root   *Directory
+	focus  *pathFocus
+	glyphs connectorSet
+	lines  *lineNumberWriter
+	// whole is the walked root when dir is an --anchor subtree of it.
+	whole      *Directory
+	breadcrumb string
+	heatMax    int
+	dirAuthors map[string]string
 }
 
 // PrintTree renders the directory tree rooted at dir using the provided label for the root.
@@ -28,35 +262,309 @@ func PrintTree(rootLabel string, dir *Directory, opts PrinterOptions) error {
 		writer = os.Stdout
 	}
 
+	if opts.Format == FormatHTML {
+		return PrintHTMLPage([]HTMLTree{{Label: rootLabel, Dir: dir}}, opts)
+	}
+
+	rootLabel, dir, opts, err := prepareTree(rootLabel, dir, opts)
+	if err != nil {
+		return err
+	}
+
+	switch opts.Format {
+	case "", FormatTree:
+		return renderText(writer, rootLabel, dir, opts)
+	case FormatUL:
+		return renderUL(writer, rootLabel, dir, opts)
+	case FormatD3:
+		return renderD3(writer, rootLabel, dir, opts)
+	case FormatURLs:
+		return renderURLs(writer, dir, opts)
+	case FormatMermaid:
+		return renderMermaid(writer, rootLabel, dir, opts)
+	case FormatIndent:
+		return renderIndent(writer, rootLabel, dir, opts)
+	case FormatSVG:
+		return renderSVG(writer, rootLabel, dir, opts)
+	case FormatPaths:
+		return renderPathsJSON(writer, dir)
+	case FormatJSON:
+		return renderJSON(writer, rootLabel, dir, opts)
+	default:
+		return fmt.Errorf("unknown format %q", opts.Format)
+	}
+}
+
+// prepareTree resolves the anchor and fills in the unexported options every
+// renderer relies on for one root.
+func prepareTree(rootLabel string, dir *Directory, opts PrinterOptions) (string, *Directory, PrinterOptions, error) {
+	opts.whole = dir
+	if opts.Anchor != "" {
+		anchor, crumbs, err := FindDirectory(dir, opts.Anchor)
+		if err != nil {
+			return "", nil, opts, err
+		}
+		if len(crumbs) > 0 {
+			opts.breadcrumb = strings.Join(append([]string{rootLabel}, crumbs...), " › ")
+			rootLabel = anchor.Name + "/"
+			dir = anchor
+		}
+	}
+
+	if opts.PathTo != "" {
+		opts.focus = newPathFocus(dir, opts.PathTo)
+	}
+	charset := opts.Charset
+	if charset == "" {
+		charset = CharsetUnicode
+	}
+	glyphs, ok := connectorSets[charset]
+	if !ok {
+		return "", nil, opts, fmt.Errorf("unknown charset %q", opts.Charset)
+	}
+	opts.glyphs = glyphs
+	opts.root = dir
+	if opts.Heatmap {
+		opts.heatMax = heatmapMax(dir)
+	}
+	if opts.Authors != nil {
+		opts.dirAuthors = dominantAuthors(opts.whole, opts.Authors)
+	}
+	return rootLabel, dir, opts, nil
+}
+
+// renderText writes the text tree through a buffered writer so large trees
+// do not pay for one write call per line. The first write error is reported
+// when the buffer is flushed.
+func renderText(w io.Writer, rootLabel string, dir *Directory, opts PrinterOptions) error {
+	buffered := bufio.NewWriter(w)
+	var out io.Writer = buffered
+	var truncating *truncatingWriter
+	if opts.Width > 0 {
+		truncating = newTruncatingWriter(buffered, opts.Width)
+		out = truncating
+	}
+	if opts.NumberLines {
+		opts.lines = newLineNumberWriter(out)
+		out = opts.lines
+	}
+
+	withColor(opts.UseColor, func(palette palette) {
+		printTree(out, rootLabel, dir, opts, palette)
+	})
+
+	if truncating != nil {
+		if err := truncating.Flush(); err != nil {
+			return err
+		}
+	}
+	return buffered.Flush()
+}
+
+func printTree(writer io.Writer, rootLabel string, dir *Directory, opts PrinterOptions, palette palette) {
+	if opts.Overview {
+		printOverview(writer, rootLabel, dir, opts, palette)
+	}
+
+	if opts.breadcrumb != "" {
+		fmt.Fprintln(writer, palette.summary.Sprintf("%s", opts.breadcrumb))
+	}
+
+	headerMode := linesNumbered
+	if opts.NumberEntriesOnly {
+		headerMode = linesPadded
+	}
+
+	opts.lines.setMode(headerMode)
+	if !opts.NoRoot {
+		if opts.Shape {
+			rootLabel = shapeDirLabel(dir)
+		}
+		fmt.Fprintln(writer, hyperlink(palette.dir.Sprintf("%s", rootLabel), dir.Path, opts)+dirAnnotations(dir, opts, palette)+dirMetaComment(dir, opts))
+	}
+
+	opts.lines.setMode(linesNumbered)
+	printChildren(writer, dir, "", "", opts, palette)
+
+	whole := opts.whole
+	opts.lines.setMode(headerMode)
+	if !opts.NoReport {
+		report := fmt.Sprintf("%d directories, %d files", whole.TotalDirs+1, whole.TotalFiles)
+		if opts.DiskUsage {
+			report += ", " + FormatSize(whole.TotalSize)
+		}
+		fmt.Fprintf(writer, "%s\n", palette.stats.Sprintf("[%s]", report))
+	}
+	opts.lines.setMode(linesPlain)
+	if opts.WalkTimeout > 0 {
+		fmt.Fprintln(writer, palette.err.Sprintf("[walk timed out after %s; the tree is partial]", opts.WalkTimeout))
+	}
+	if opts.ExcludedReport {
+		fmt.Fprintln(writer, palette.stats.Sprintf("[%d files matched the exclude patterns]", whole.TotalFiles))
+	}
+	if opts.Totals {
+		printTotals(writer, whole, opts, palette)
+	}
+	if opts.ExtSummary {
+		printExtSummary(writer, whole, opts, palette)
+	}
+	if opts.ExtChurn != nil {
+		printExtChurn(writer, opts, palette)
+	}
+	if opts.ReadmeCheck {
+		printMissingReadmes(writer, whole, palette)
+	}
+	if opts.ShowLanguages {
+		printLanguages(writer, whole, opts, palette)
+	}
+	if opts.Balance {
+		printBalance(writer, whole, palette)
+	}
+	if opts.NameStats {
+		printNameStats(writer, whole, opts, palette)
+	}
+	if opts.LintNames != nil {
+		printLintSummary(writer, whole, opts, palette)
+	}
+}
+
+func printLintSummary(writer io.Writer, dir *Directory, opts PrinterOptions, palette palette) {
+	count := CountNameViolations(dir, opts.LintNames)
+	if count == 0 {
+		fmt.Fprintln(writer, palette.summary.Sprintf("all file names match %s", opts.LintNames))
+		return
+	}
+	fmt.Fprintln(writer, palette.warn.Sprintf("%d file names do not match %s", count, opts.LintNames))
+}
+
+func printNameStats(writer io.Writer, dir *Directory, opts PrinterOptions, palette palette) {
+	stats := SummarizeNames(dir, opts.NameLimit)
+	fmt.Fprintln(writer, palette.summary.Sprintf("name length  min %d, max %d, mean %.1f over %d files", stats.Min, stats.Max, stats.Mean, stats.Files))
+	if stats.OverLimit > 0 {
+		fmt.Fprintln(writer, palette.err.Sprintf("%d names longer than %d bytes", stats.OverLimit, opts.NameLimit))
+	}
+}
+
+func printBalance(writer io.Writer, dir *Directory, palette palette) {
+	stats := TreeBalance(dir)
+	fmt.Fprintln(writer, palette.summary.Sprintf("max depth        %d", stats.MaxDepth))
+	fmt.Fprintln(writer, palette.summary.Sprintf("mean file depth  %.2f", stats.MeanFileDepth))
+	fmt.Fprintln(writer, palette.summary.Sprintf("file depth sd    %.2f", stats.StdDevFileDepth))
+}
+
+func printLanguages(writer io.Writer, dir *Directory, opts PrinterOptions, palette palette) {
+	stats := SummarizeLanguages(dir, opts.LanguagesBySize)
+	width := 0
+	for _, stat := range stats {
+		if len(stat.Language) > width {
+			width = len(stat.Language)
+		}
+	}
+	for _, stat := range stats {
+		fmt.Fprintln(writer, palette.summary.Sprintf("%-*s  %5.1f%%  (%d files, %s)", width, stat.Language, stat.Percent, stat.Files, FormatSize(stat.Bytes)))
+	}
+}
+
+// TotalsCategories lists the categories printed by PrinterOptions.Totals.
+var TotalsCategories = []string{"directories", "files", "symlinks", "errors"}
+
+func printTotals(writer io.Writer, dir *Directory, opts PrinterOptions, palette palette) {
+	counts := map[string]int{
+		"directories": dir.TotalDirs + 1,
+		"files":       dir.TotalFiles,
+		"symlinks":    dir.TotalSymlinks,
+		"errors":      dir.TotalErrors,
+	}
+	omitted := make(map[string]bool, len(opts.TotalsOmit))
+	for _, category := range opts.TotalsOmit {
+		omitted[category] = true
+	}
+	for _, category := range TotalsCategories {
+		if omitted[category] {
+			continue
+		}
+		fmt.Fprintln(writer, palette.summary.Sprintf("%-11s  %d", category, counts[category]))
+	}
+}
+
+func printMissingReadmes(writer io.Writer, dir *Directory, palette palette) {
+	missing := MissingReadmes(dir)
+	fmt.Fprintln(writer, palette.stats.Sprintf("[%d directories missing a README]", len(missing)))
+	for _, rel := range missing {
+		fmt.Fprintln(writer, palette.summary.Sprintf("%s", rel))
+	}
+}
+
+// overviewDepth is the number of levels shown by PrinterOptions.Overview.
+const overviewDepth = 2
+
+func printOverview(writer io.Writer, rootLabel string, dir *Directory, opts PrinterOptions, palette palette) {
+	overview := opts
+	overview.MaxDepth = overviewDepth
+	overview.HideFiles = true
+	overview.DirTotals = true
+	if !overview.NoRoot {
+		fmt.Fprintln(writer, hyperlink(palette.dir.Sprintf("%s", rootLabel), dir.Path, opts)+dirAnnotations(dir, overview, palette))
+	}
+	printChildren(writer, dir, "", "", overview, palette)
+	fmt.Fprintln(writer)
+}
+
+// withColor runs fn with a fresh palette while color output is forced on or
+// off, restoring the global setting afterwards.
+func withColor(useColor bool, fn func(palette palette)) {
 	originalNoColor := color.NoColor
-	color.NoColor = !opts.UseColor
+	color.NoColor = !useColor
 	defer func() {
 		color.NoColor = originalNoColor
 	}()
+	fn(newPalette())
+}
 
-	palette := newPalette()
-	fmt.Fprintln(writer, palette.dir.Sprintf("%s", rootLabel))
+func printExtSummary(writer io.Writer, dir *Directory, opts PrinterOptions, palette palette) {
+	stats := SummarizeExtensions(dir, opts.PreserveExtCase)
+	width := 0
+	for _, stat := range stats {
+		if len(stat.Label) > width {
+			width = len(stat.Label)
+		}
+	}
+	for _, stat := range stats {
+		fmt.Fprintln(writer, palette.summary.Sprintf("%-*s  %d", width, stat.Label, stat.Count))
+	}
+}
 
-	printChildren(writer, dir, "", opts, palette)
-	fmt.Fprintf(writer, "%s\n", palette.stats.Sprintf("[%d directories, %d files]", dir.TotalDirs+1, dir.TotalFiles))
-	return nil
+func printExtChurn(writer io.Writer, opts PrinterOptions, palette palette) {
+	if len(opts.ExtChurn) == 0 {
+		fmt.Fprintln(writer, palette.summary.Sprintf("no files changed since %s", opts.ExtChurnRef))
+		return
+	}
+	parts := make([]string, 0, len(opts.ExtChurn))
+	for _, stat := range opts.ExtChurn {
+		parts = append(parts, fmt.Sprintf("%s: %d changed", stat.Label, stat.Count))
+	}
+	fmt.Fprintln(writer, palette.summary.Sprintf("since %s  %s", opts.ExtChurnRef, strings.Join(parts, ", ")))
 }
 
 type palette struct {
-	dir     *color.Color
-	file    *color.Color
-	summary *color.Color
-	stats   *color.Color
-	err     *color.Color
+	dir       *color.Color
+	file      *color.Color
+	summary   *color.Color
+	stats     *color.Color
+	err       *color.Color
+	highlight *color.Color
+	warn      *color.Color
 }
 
 func newPalette() palette {
 	return palette{
-		dir:     color.New(color.FgBlue, color.Bold),
-		file:    color.New(color.FgWhite),
-		summary: color.New(color.Faint),
-		stats:   color.New(color.FgGreen, color.Bold),
-		err:     color.New(color.FgRed, color.Bold),
+		dir:       color.New(color.FgBlue, color.Bold),
+		file:      color.New(color.FgWhite),
+		summary:   color.New(color.Faint),
+		stats:     color.New(color.FgGreen, color.Bold),
+		err:       color.New(color.FgRed, color.Bold),
+		highlight: color.New(color.FgYellow, color.Bold),
+		warn:      color.New(color.FgYellow),
 	}
 }
 
@@ -67,6 +575,10 @@ const (
 	itemCollapse
 	itemFile
 	itemFileSummary
+	itemElided
+	itemNumbered
+	itemDateGroup
+	itemSection
 )
 
 type treeItem struct {
@@ -74,76 +586,308 @@ type treeItem struct {
 	dir           *Directory
 	file          FileEntry
 	collapseCount int
+	numbered      *NumberedGroup
+	dateGroup     *dateGroup
+	section       string
+	similar       bool
 }
 
-func printChildren(writer io.Writer, dir *Directory, prefix string, opts PrinterOptions, palette palette) {
+// printChildren renders the entries of dir. relDir is dir's path relative to
+// the rendered root and is only used to label files with --paths-in-tree.
+func printChildren(writer io.Writer, dir *Directory, prefix, relDir string, opts PrinterOptions, palette palette) {
 	items := buildItems(dir, opts)
+	var largestSibling int64
+	if opts.SizeBars {
+		for _, child := range dir.Subdirs {
+			largestSibling = max(largestSibling, child.TotalSize)
+		}
+	}
 	for idx, item := range items {
 		isLast := idx == len(items)-1
-		connector := "├── "
+		connector := opts.glyphs.branch
 		if isLast {
-			connector = "└── "
+			connector = opts.glyphs.last
+		}
+		nextPrefix := extendPrefix(prefix, isLast, opts.glyphs)
+		if opts.NoRoot && dir == opts.root {
+			connector = ""
+			nextPrefix = ""
 		}
 
 		switch item.kind {
 		case itemDir:
 			child := item.dir
-			label := child.Name
+			dirColor := palette.dir
+			if opts.focus != nil && opts.focus.onPath[child] {
+				dirColor = palette.highlight
+			}
+			if child.Ghost {
+				dirColor = palette.err
+			}
+			label := dirLabel(child, opts)
 			if child.Err != nil {
 				msg := errorMessage(child, palette)
-				fmt.Fprintf(writer, "%s%s%s %s\n", prefix, connector, palette.dir.Sprintf("%s", label), msg)
+				fmt.Fprintf(writer, "%s%s%s %s%s\n", prefix, connector, hyperlink(dirColor.Sprintf("%s", label), child.Path, opts), msg, dirMetaComment(child, opts))
 			} else {
-				fmt.Fprintf(writer, "%s%s%s/\n", prefix, connector, palette.dir.Sprintf("%s", label))
-				nextPrefix := extendPrefix(prefix, isLast)
-				printChildren(writer, child, nextPrefix, opts, palette)
+				painted := dirColor.Sprintf("%s", label)
+				if heatmapEnabled(opts) {
+					painted = heatmapLabel(label, child.TotalFiles, opts.heatMax)
+				}
+				annotations := dirAnnotations(child, opts, palette)
+				if opts.SizeBars && opts.DiskUsage {
+					annotations += " " + palette.stats.Sprintf("%s", sizeBar(child.TotalSize, largestSibling, opts.glyphs))
+				}
+				slash := "/"
+				if opts.LabelTemplate != nil || opts.Shape {
+					slash = ""
+				}
+				fmt.Fprintf(writer, "%s%s%s%s%s%s\n", prefix, connector, hyperlink(painted, child.Path, opts), slash, annotations, dirMetaComment(child, opts))
+				if !isFolded(child, opts) && !isPackageCollapsed(child, opts) {
+					printChildren(writer, child, nextPrefix, filepath.Join(relDir, child.Name), opts, palette)
+				}
 			}
 		case itemCollapse:
-			fmt.Fprintf(writer, "%s%s%s\n", prefix, connector, palette.summary.Sprintf("... (%d identical dirs)", item.collapseCount))
+			fmt.Fprintf(writer, "%s%s%s\n", prefix, connector, palette.summary.Sprintf("%s", itemSummary(dir, item)))
 		case itemFile:
-			fmt.Fprintf(writer, "%s%s%s\n", prefix, connector, palette.file.Sprintf("%s", item.file.Name))
+			printFile(writer, dir, item.file, prefix+connector, nextPrefix, relDir, opts, palette)
+		case itemDateGroup:
+			group := item.dateGroup
+			fmt.Fprintf(writer, "%s%s%s\n", prefix, connector, palette.summary.Sprintf("%s", itemSummary(dir, item)))
+			for idx, file := range group.files {
+				fileConnector := opts.glyphs.branch
+				if idx == len(group.files)-1 {
+					fileConnector = opts.glyphs.last
+				}
+				printFile(writer, dir, file, nextPrefix+fileConnector, extendPrefix(nextPrefix, idx == len(group.files)-1, opts.glyphs), relDir, opts, palette)
+			}
 		case itemFileSummary:
+			fmt.Fprintf(writer, "%s%s%s\n", prefix, connector, palette.summary.Sprintf("%s", itemSummary(dir, item)))
+		case itemElided, itemSection:
+			fmt.Fprintf(writer, "%s%s%s\n", prefix, connector, palette.summary.Sprintf("%s", itemSummary(dir, item)))
+		case itemNumbered:
+			group := item.numbered
+			label := group.Label + "/"
+			if opts.Shape {
+				label = shapeNumbered
+			}
 			fmt.Fprintf(
 				writer,
-				"%s%s%s\n",
+				"%s%s%s %s\n",
 				prefix,
 				connector,
-				palette.summary.Sprintf(
-					"... [%d directories, %d files, showing first %d]",
-					dir.ImmediateDirCount,
-					dir.ImmediateFileCount,
-					dir.ImmediateFileCount-item.collapseCount,
-				),
+				palette.dir.Sprintf("%s", label),
+				palette.summary.Sprintf("(%d dirs)", len(group.Members)),
+			)
+			if representative := group.Members[0]; opts.ExpandNumbered && representative.Err == nil {
+				printChildren(writer, representative, nextPrefix, filepath.Join(relDir, representative.Name), opts, palette)
+			}
+		}
+	}
+}
+
+// hyperlink wraps text in an OSC 8 terminal hyperlink to path's file:// URL.
+// Terminals without OSC 8 support ignore the escapes.
+func hyperlink(text, path string, opts PrinterOptions) string {
+	if !opts.Hyperlinks || !opts.UseColor || opts.Shape {
+		return text
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return text
+	}
+	target := url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}
+	return "\x1b]8;;" + target.String() + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// displayPath applies StripPrefix to a full path label.
+func displayPath(path string, opts PrinterOptions) string {
+	if opts.StripPrefix != "" && strings.HasPrefix(path, opts.StripPrefix) {
+		return strings.TrimPrefix(path, opts.StripPrefix)
+	}
+	return path
+}
+
+// printFile renders one file line of dir, followed by its preview lines.
+// lead holds the prefix and connector that precede the name, and cont the
+// prefix continuing below it.
+func printFile(writer io.Writer, dir *Directory, file FileEntry, lead, cont, relDir string, opts PrinterOptions, palette palette) {
+	fileColor := palette.file
+	if opts.focus != nil {
+		fileColor = palette.highlight
+	}
+	if file.Ghost {
+		fileColor = palette.err
+	} else if violatesNaming(file, opts) {
+		fileColor = palette.warn
+	}
+	label := fileColor.Sprintf("%s", fileLabel(dir, file, relDir, opts))
+	if !file.Ghost {
+		label = hyperlink(label, filepath.Join(dir.Path, file.Name), opts)
+	}
+	fmt.Fprintf(writer, "%s%s%s%s\n", lead, label, fileAnnotations(dir, file, opts, palette), fileMetaComment(dir, file, opts))
+	if opts.Shape {
+		return
+	}
+	for _, line := range file.Preview {
+		fmt.Fprintf(writer, "%s  %s\n", cont, palette.summary.Sprintf("%s", line))
+	}
+	if file.PreviewTruncated {
+		fmt.Fprintf(writer, "%s  %s\n", cont, palette.summary.Sprintf("..."))
+	}
+}
+
+// itemSummary returns the text of a collapse, file-summary or elision item
+// listed inside dir.
+func itemSummary(dir *Directory, item treeItem) string {
+	switch item.kind {
+	case itemCollapse:
+		if item.similar {
+			return fmt.Sprintf("... (%d similar dirs)", item.collapseCount)
+		}
+		return fmt.Sprintf("... (%d identical dirs)", item.collapseCount)
+	case itemFileSummary:
+		if dir.Sampled {
+			return fmt.Sprintf(
+				"... [%d directories, %d files, showing %d sampled]",
+				dir.ImmediateDirCount,
+				dir.ImmediateFileCount,
+				dir.ImmediateFileCount-item.collapseCount,
 			)
 		}
+		return fmt.Sprintf(
+			"... [%d directories, %d files, showing first %d]",
+			dir.ImmediateDirCount,
+			dir.ImmediateFileCount,
+			dir.ImmediateFileCount-item.collapseCount,
+		)
+	case itemElided:
+		return "..."
+	case itemNumbered:
+		return fmt.Sprintf("%s/ (%d dirs)", item.numbered.Label, len(item.numbered.Members))
+	case itemDateGroup:
+		return fmt.Sprintf("%s (%d files)", item.dateGroup.label, len(item.dateGroup.files))
+	case itemSection:
+		return fmt.Sprintf("-- %s --", item.section)
 	}
+	return ""
 }
 
 func buildItems(dir *Directory, opts PrinterOptions) []treeItem {
+	if opts.MaxDepth > 0 && dir.Level-opts.root.Level >= opts.MaxDepth {
+		return nil
+	}
+	if opts.focus != nil {
+		return buildFocusedItems(dir, opts.focus, opts.HideFiles)
+	}
+
 	maxDirs := opts.MaxDirs
 	if maxDirs <= 0 {
 		maxDirs = math.MaxInt
 	}
 
-	groups := GroupIdentical(dir.Subdirs)
-	items := make([]treeItem, 0, len(dir.Subdirs)+len(dir.Files)+1)
+	// Each chunk of directory items is placed at the position of its first
+	// member so numbered and identical groups interleave in walk order.
+	type dirChunk struct {
+		pos   int
+		lead  *Directory
+		items []treeItem
+	}
+	ordered := dir.Subdirs
+	if opts.DiskUsageSort {
+		ordered = slices.Clone(ordered)
+		sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].TotalSize > ordered[j].TotalSize })
+	} else if opts.SectionChars > 0 {
+		ordered = slices.Clone(ordered)
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return sectionKey(ordered[i].Name, opts.SectionChars, opts.SectionCaseSensitive) < sectionKey(ordered[j].Name, opts.SectionChars, opts.SectionCaseSensitive)
+		})
+	}
+	position := make(map[*Directory]int, len(ordered))
+	for idx, child := range ordered {
+		position[child] = idx
+	}
+
+	var chunks []dirChunk
+	subdirs := ordered
+	if opts.CollapseNumbered {
+		var numbered []NumberedGroup
+		numbered, subdirs = GroupNumbered(ordered)
+		for idx := range numbered {
+			group := &numbered[idx]
+			chunks = append(chunks, dirChunk{
+				pos:   position[group.Members[0]],
+				lead:  group.Members[0],
+				items: []treeItem{{kind: itemNumbered, numbered: group}},
+			})
+		}
+	}
 
-	for _, group := range groups {
+	for _, group := range GroupSimilar(subdirs, similarity(opts)) {
 		limit := len(group.Members)
 		if limit > maxDirs {
 			limit = maxDirs
 		}
+		chunk := dirChunk{pos: position[group.Members[0]], lead: group.Members[0]}
 		for i := 0; i < limit; i++ {
-			items = append(items, treeItem{kind: itemDir, dir: group.Members[i]})
+			chunk.items = append(chunk.items, treeItem{kind: itemDir, dir: group.Members[i]})
 		}
 		if len(group.Members) > limit {
-			items = append(items, treeItem{kind: itemCollapse, collapseCount: len(group.Members) - limit})
+			chunk.items = append(chunk.items, treeItem{kind: itemCollapse, collapseCount: len(group.Members) - limit, similar: group.Similar})
+		}
+		chunks = append(chunks, chunk)
+	}
+	sort.SliceStable(chunks, func(i, j int) bool { return chunks[i].pos < chunks[j].pos })
+
+	items := make([]treeItem, 0, len(dir.Subdirs)+len(dir.Files)+1)
+	if opts.HideFiles {
+		for _, chunk := range chunks {
+			items = append(items, chunk.items...)
 		}
+		return insertSections(items, opts)
+	}
+
+	files := dir.Files
+	if opts.DiskUsageSort {
+		files = slices.Clone(files)
+		sort.SliceStable(files, func(i, j int) bool { return files[i].Size > files[j].Size })
+	} else if opts.SectionChars > 0 {
+		// Folding case can put entries of one section apart in name order,
+		// so regroup them while keeping the order within each section.
+		files = slices.Clone(files)
+		sort.SliceStable(files, func(i, j int) bool {
+			return sectionKey(files[i].Name, opts.SectionChars, opts.SectionCaseSensitive) < sectionKey(files[j].Name, opts.SectionChars, opts.SectionCaseSensitive)
+		})
 	}
 
-	for _, file := range dir.Files {
-		items = append(items, treeItem{kind: itemFile, file: file})
+	if opts.DateBuckets != nil {
+		for _, chunk := range chunks {
+			items = append(items, chunk.items...)
+		}
+		for _, group := range bucketFiles(files, opts.DateBuckets) {
+			group := group
+			items = append(items, treeItem{kind: itemDateGroup, dateGroup: &group})
+		}
+	} else {
+		if opts.DirsFirst || opts.SortBy == SortNone {
+			for _, chunk := range chunks {
+				items = append(items, chunk.items...)
+			}
+			chunks = nil
+		}
+		for _, file := range files {
+			for len(chunks) > 0 && !opts.sortsBefore(fileSortKey(file), dirSortKey(chunks[0].lead)) {
+				items = append(items, chunks[0].items...)
+				chunks = chunks[1:]
+			}
+			items = append(items, treeItem{kind: itemFile, file: file})
+		}
+		for _, chunk := range chunks {
+			items = append(items, chunk.items...)
+		}
 	}
 
+	items = insertSections(items, opts)
+
 	if dir.HiddenFiles > 0 {
 		items = append(items, treeItem{kind: itemFileSummary, collapseCount: dir.HiddenFiles})
 	}
@@ -151,20 +895,154 @@ func buildItems(dir *Directory, opts PrinterOptions) []treeItem {
 	return items
 }
 
-func extendPrefix(prefix string, isLast bool) string {
+// dirAnnotations returns the optional suffix rendered after a directory label.
+func dirAnnotations(dir *Directory, opts PrinterOptions, palette palette) string {
+	suffix := manifestMarker(dir.Expected, dir.Ghost, palette)
+	if dir.LinkTarget != "" {
+		suffix += " -> " + dir.LinkTarget
+	}
+	if dir.SymlinkCycle {
+		suffix += " " + palette.err.Sprintf("[symlink cycle]")
+	}
+	if opts.ReadmeCheck && readmeCheckable(dir) {
+		if dir.HasReadme {
+			suffix += " " + palette.stats.Sprintf("✓")
+		} else {
+			suffix += " " + palette.summary.Sprintf("✗")
+		}
+	}
+
+	if opts.ShowFilteredCounts && dir.FilteredEntries > 0 {
+		shown := dir.ImmediateDirCount + dir.ImmediateFileCount
+		suffix += " " + palette.summary.Sprintf("(%d shown, %d filtered)", shown, dir.FilteredEntries)
+	}
+
+	var parts []string
+	if dir.Unexpanded {
+		parts = append(parts, fmt.Sprintf("%d entries, not expanded", dir.EntryCount))
+	}
+	if dir.OtherFilesystem {
+		parts = append(parts, "other filesystem")
+	}
+	if dir.StoppedAt {
+		parts = append(parts, "not descended")
+	}
+	if dir.Archive {
+		parts = append(parts, "archive")
+	}
+	if dir.TimedOut {
+		parts = append(parts, "timed out")
+	}
+	if opts.DirTotals && dir.Err == nil {
+		parts = append(parts, fmt.Sprintf("%d dirs, %d files", dir.TotalDirs, dir.TotalFiles))
+	}
+	if opts.ShowEntropy {
+		parts = append(parts, fmt.Sprintf("H=%.2f", ExtensionEntropy(dir.ExtCounts)))
+	}
+	if isFolded(dir, opts) {
+		parts = append(parts, fmt.Sprintf("%d files total, folded", dir.TotalFiles))
+	}
+	if author := opts.dirAuthors[dir.Path]; author != "" {
+		parts = append(parts, "mostly "+author)
+	}
+	if opts.Packages && dir.Package != nil {
+		parts = append(parts, "package "+dir.Package.Label())
+		if isPackageCollapsed(dir, opts) {
+			parts = append(parts, fmt.Sprintf("%d dirs, %d files", dir.TotalDirs, dir.TotalFiles))
+		}
+	}
+	if opts.DiskUsage && dir.Err == nil && !dir.Leaf {
+		parts = append(parts, FormatSize(dir.TotalSize))
+	}
+	if len(parts) == 0 {
+		return suffix
+	}
+	return suffix + " " + palette.summary.Sprintf("[%s]", strings.Join(parts, ", "))
+}
+
+// sizeBarWidth is the number of cells in a --bars bar.
+const sizeBarWidth = 8
+
+// sizeBar draws size as a share of largest, rounding any non-zero size up to
+// at least one full cell.
+func sizeBar(size, largest int64, glyphs connectorSet) string {
+	full := 0
+	if largest > 0 && size > 0 {
+		full = max(1, int(math.Round(float64(size)*sizeBarWidth/float64(largest))))
+	}
+	return strings.Repeat(glyphs.barFull, full) + strings.Repeat(glyphs.barEmpty, sizeBarWidth-full)
+}
+
+// isFolded reports whether dir is collapsed to one line by FoldAt.
+func isFolded(dir *Directory, opts PrinterOptions) bool {
+	return opts.FoldAt > 0 && dir != opts.root && dir.TotalFiles > opts.FoldAt
+}
+
+// fileAnnotations returns the optional suffix rendered after a file name.
+func fileAnnotations(dir *Directory, file FileEntry, opts PrinterOptions, palette palette) string {
+	suffix := manifestMarker(file.Expected, file.Ghost, palette)
+	if opts.NameStats && opts.NameLimit > 0 && len(file.Name) > opts.NameLimit {
+		suffix += " " + palette.err.Sprintf("[name %d > %d bytes]", len(file.Name), opts.NameLimit)
+	}
+	if violatesNaming(file, opts) {
+		suffix += " " + palette.warn.Sprintf("[naming]")
+	}
+	if file.Special != "" {
+		suffix += " " + palette.summary.Sprintf("[%s]", file.Special)
+	} else if opts.DiskUsage && !file.Ghost {
+		suffix += " " + palette.summary.Sprintf("[%s]", FormatSize(file.Size))
+	}
+	if opts.ShowLanguages {
+		if lang := LanguageFor(file.Name); lang != "" {
+			suffix += " " + palette.summary.Sprintf("[%s]", lang)
+		}
+	}
+	if opts.MatchCounts && file.Matches > 0 {
+		noun := "matches"
+		if file.Matches == 1 {
+			noun = "match"
+		}
+		suffix += " " + palette.summary.Sprintf("[%d %s]", file.Matches, noun)
+	}
+	if author := opts.Authors[filepath.Join(dir.Path, file.Name)]; author != "" {
+		suffix += " " + palette.summary.Sprintf("[%s]", author)
+	}
+	return suffix
+}
+
+// manifestMarker returns the ✓/✗ marker of entries checked by MarkExpected.
+func manifestMarker(expected, ghost bool, palette palette) string {
+	switch {
+	case ghost:
+		return " " + palette.err.Sprintf("✗")
+	case expected:
+		return " " + palette.stats.Sprintf("✓")
+	}
+	return ""
+}
+
+func extendPrefix(prefix string, isLast bool, glyphs connectorSet) string {
 	if isLast {
-		return prefix + "    "
+		return prefix + glyphs.blank
 	}
-	return prefix + "│   "
+	return prefix + glyphs.pipe
 }
 
 func errorMessage(dir *Directory, palette palette) string {
 	if dir.IsPermissionError() {
-		return palette.summary.Sprintf("[Permission denied]")
+		return palette.summary.Sprintf("[%s]", errorText(dir))
+	}
+	return palette.err.Sprintf("[%s]", errorText(dir))
+}
+
+// errorText returns the uncolored description of dir's walk error.
+func errorText(dir *Directory) string {
+	if dir.IsPermissionError() {
+		return "Permission denied"
 	}
 	trimmed := strings.TrimSpace(dir.Err.Error())
 	if trimmed == "" {
 		trimmed = "error"
 	}
-	return palette.err.Sprintf("[%s]", trimmed)
+	return trimmed
 }
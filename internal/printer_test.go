@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// BenchmarkRender renders a generated tree of about 100k nodes to a file, the
+// case where per-line writes without PrintTree's buffering cost a system
+// call each. Run it on a commit before the buffering to compare.
+func BenchmarkRender(b *testing.B) {
+	var paths []string
+	for top := 0; top < 10; top++ {
+		for mid := 0; mid < 10; mid++ {
+			for leaf := 0; leaf < 10; leaf++ {
+				for file := 0; file < 100; file++ {
+					paths = append(paths, fmt.Sprintf("top%d/mid%d/leaf%d/file%d.txt", top, mid, leaf, file))
+				}
+			}
+		}
+	}
+	dir := BuildFromPaths("root", paths)
+
+	out, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer out.Close()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := PrintTree("root/", dir, PrinterOptions{Writer: out}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestHideFilesWithPathTo(t *testing.T) {
+	dir := BuildFromPaths("root", []string{"cmd/root.go", "cmd/why.go", "internal/walker.go"})
+	got := render(t, dir, PrinterOptions{PathTo: "root.go", HideFiles: true})
+	if !strings.Contains(got, "cmd/") {
+		t.Errorf("branch to the match is missing:\n%s", got)
+	}
+	if strings.Contains(got, ".go") {
+		t.Errorf("HideFiles listed a file:\n%s", got)
+	}
+}
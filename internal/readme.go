@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+var readmeExtensions = map[string]bool{
+	"":          true,
+	".md":       true,
+	".markdown": true,
+	".txt":      true,
+	".rst":      true,
+	".adoc":     true,
+	".org":      true,
+}
+
+// isReadme reports whether name is a README file in any letter case with no
+// extension or one of the common documentation extensions.
+func isReadme(name string) bool {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	return strings.EqualFold(base, "readme") && readmeExtensions[strings.ToLower(ext)]
+}
+
+// readmeCheckable reports whether dir's contents were read, so the presence
+// or absence of a README is known.
+func readmeCheckable(dir *Directory) bool {
+	return dir.Err == nil && !dir.Leaf && !dir.Unexpanded
+}
+
+// MissingReadmes returns the paths, relative to root, of every walked
+// directory that lacks a README, in tree order. The root itself is reported
+// as ".".
+func MissingReadmes(root *Directory) []string {
+	var missing []string
+	var visit func(dir *Directory, rel string)
+	visit = func(dir *Directory, rel string) {
+		if !readmeCheckable(dir) {
+			return
+		}
+		if !dir.HasReadme {
+			missing = append(missing, rel)
+		}
+		for _, child := range dir.Subdirs {
+			visit(child, filepath.Join(rel, child.Name))
+		}
+	}
+	visit(root, ".")
+	return missing
+}
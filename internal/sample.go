@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"sort"
+)
+
+// fileSampler keeps a uniform random sample of at most size files using
+// reservoir sampling. The random source is derived from the seed and the
+// directory path, so the same tree and seed always yield the same sample.
+type fileSampler struct {
+	size    int
+	seen    int
+	rng     *rand.Rand
+	picked  []FileEntry
+	indexes []int
+}
+
+func newFileSampler(size int, seed int64, path string) *fileSampler {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(path))
+	return &fileSampler{
+		size: size,
+		rng:  rand.New(rand.NewSource(seed ^ int64(hasher.Sum64()))),
+	}
+}
+
+func (s *fileSampler) offer(file FileEntry) {
+	idx := s.seen
+	s.seen++
+	if len(s.picked) < s.size {
+		s.picked = append(s.picked, file)
+		s.indexes = append(s.indexes, idx)
+		return
+	}
+	if j := s.rng.Intn(s.seen); j < s.size {
+		s.picked[j] = file
+		s.indexes[j] = idx
+	}
+}
+
+// files returns the sampled entries in their original walk order.
+func (s *fileSampler) files() []FileEntry {
+	order := make([]int, len(s.picked))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return s.indexes[order[a]] < s.indexes[order[b]]
+	})
+	result := make([]FileEntry, len(order))
+	for i, idx := range order {
+		result[i] = s.picked[idx]
+	}
+	return result
+}
+
+// hidden reports how many offered files were left out of the sample.
+func (s *fileSampler) hidden() int {
+	return s.seen - len(s.picked)
+}
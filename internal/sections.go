@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"strings"
+	"unicode"
+)
+
+// sectionKey returns the alphabetical section a name belongs to: its first
+// chars runes, upper-cased unless caseSensitive. Names starting with
+// anything other than a letter share the "#" section.
+func sectionKey(name string, chars int, caseSensitive bool) string {
+	runes := []rune(name)
+	if len(runes) == 0 || !unicode.IsLetter(runes[0]) {
+		return "#"
+	}
+	key := string(runes[:min(chars, len(runes))])
+	if !caseSensitive {
+		key = strings.ToUpper(key)
+	}
+	return key
+}
+
+// insertSections places a section header before every directory or file
+// whose key differs from that of the entry before it. Other items, such as
+// collapse lines, belong to the current section. Items are returned
+// unchanged unless SectionChars is positive.
+func insertSections(items []treeItem, opts PrinterOptions) []treeItem {
+	if opts.SectionChars <= 0 {
+		return items
+	}
+	sectioned := make([]treeItem, 0, len(items))
+	current := ""
+	for _, item := range items {
+		name := ""
+		switch item.kind {
+		case itemDir:
+			name = item.dir.Name
+		case itemFile:
+			name = item.file.Name
+		}
+		if name != "" {
+			if key := sectionKey(name, opts.SectionChars, opts.SectionCaseSensitive); key != current {
+				sectioned = append(sectioned, treeItem{kind: itemSection, section: key})
+				current = key
+			}
+		}
+		sectioned = append(sectioned, item)
+	}
+	return sectioned
+}
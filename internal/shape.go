@@ -0,0 +1,15 @@
+package internal
+
+import "fmt"
+
+// Labels drawn by PrinterOptions.Shape in place of names.
+const (
+	shapeFile     = "."
+	shapeNumbered = "[...]"
+)
+
+// shapeDirLabel returns the label Shape draws for dir: its recursive file
+// count in brackets.
+func shapeDirLabel(dir *Directory) string {
+	return fmt.Sprintf("[%d]", dir.TotalFiles)
+}
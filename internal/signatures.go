@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WriteSignatures writes one "path<TAB>signature" line per directory below
+// and including root, with slash-separated paths relative to root ("." for
+// the root itself). The output can later be loaded with ReadSignatures.
+func WriteSignatures(w io.Writer, root *Directory) error {
+	bw := bufio.NewWriter(w)
+	var visit func(dir *Directory, rel string)
+	visit = func(dir *Directory, rel string) {
+		fmt.Fprintf(bw, "%s\t%s\n", filepath.ToSlash(rel), dir.Signature)
+		for _, child := range dir.Subdirs {
+			visit(child, filepath.Join(rel, child.Name))
+		}
+	}
+	visit(root, ".")
+	return bw.Flush()
+}
+
+// WriteSignaturesFile is WriteSignatures for a named file.
+func WriteSignaturesFile(name string, root *Directory) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := WriteSignatures(f, root); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadSignatures loads a manifest produced by WriteSignatures.
+func ReadSignatures(r io.Reader) (map[string]string, error) {
+	sigs := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if text == "" {
+			continue
+		}
+		rel, sig, ok := strings.Cut(text, "\t")
+		if !ok {
+			return nil, fmt.Errorf("signature manifest line %d: missing tab separator", line)
+		}
+		sigs[rel] = sig
+	}
+	return sigs, scanner.Err()
+}
+
+// ReadSignaturesFile is ReadSignatures for a named file.
+func ReadSignaturesFile(name string) (map[string]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadSignatures(f)
+}
+
+// PruneUnchanged returns a copy of root that keeps only the directories whose
+// signature differs from the baseline or that are missing from it, plus the
+// directories leading to them. A directory's signature does not always cover
+// all of its descendants (see Options.SignatureDepth), so unchanged
+// directories are still searched for changed ones below. Counts and totals
+// are those of the full walk. It returns nil when nothing changed.
+func PruneUnchanged(root *Directory, baseline map[string]string) *Directory {
+	return pruneUnchanged(root, ".", baseline)
+}
+
+func pruneUnchanged(dir *Directory, rel string, baseline map[string]string) *Directory {
+	pruned := *dir
+	pruned.Subdirs = nil
+	for _, child := range dir.Subdirs {
+		if kept := pruneUnchanged(child, filepath.Join(rel, child.Name), baseline); kept != nil {
+			pruned.Subdirs = append(pruned.Subdirs, kept)
+		}
+	}
+	if sig, ok := baseline[filepath.ToSlash(rel)]; ok && sig == dir.Signature && len(pruned.Subdirs) == 0 {
+		return nil
+	}
+	return &pruned
+}
@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPruneUnchangedBelowSignatureDepth(t *testing.T) {
+	root := writeTree(t, "a/x/deep/one.go", "b/two.go")
+	opts := Options{SignatureDepth: 1}
+	before, err := Walk(root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest strings.Builder
+	if err := WriteSignatures(&manifest, before); err != nil {
+		t.Fatal(err)
+	}
+	baseline, err := ReadSignatures(strings.NewReader(manifest.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A change two levels below a leaves a's shallow signature as it was.
+	if err := os.WriteFile(filepath.Join(root, "a", "x", "deep", "new.md"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	after, err := Walk(root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Subdirs[0].Signature != before.Subdirs[0].Signature {
+		t.Fatal("a's signature changed; the test no longer covers an unchanged ancestor")
+	}
+
+	pruned := PruneUnchanged(after, baseline)
+	if pruned == nil {
+		t.Fatal("PruneUnchanged found no change")
+	}
+	var kept []string
+	for dir, rel := pruned, ""; ; {
+		if len(dir.Subdirs) != 1 {
+			t.Fatalf("%s: kept %d subdirectories, want 1", rel, len(dir.Subdirs))
+		}
+		dir = dir.Subdirs[0]
+		rel = filepath.ToSlash(filepath.Join(rel, dir.Name))
+		kept = append(kept, rel)
+		if rel == "a/x/deep" {
+			break
+		}
+	}
+	if got, want := strings.Join(kept, " "), "a a/x a/x/deep"; got != want {
+		t.Errorf("kept %s, want %s", got, want)
+	}
+}
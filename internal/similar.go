@@ -0,0 +1,86 @@
+package internal
+
+// GroupSimilar partitions directories like GroupIdentical, but also puts a
+// directory in the first earlier group whose first member is at least
+// threshold similar to it (see DirSimilarity). Groups keep the order in which
+// their first member appears, and members their original order. A threshold
+// of 1 or more groups exact matches only, exactly like GroupIdentical.
+func GroupSimilar(dirs []*Directory, threshold float64) []DirGroup {
+	if threshold >= 1 {
+		return GroupIdentical(dirs)
+	}
+
+	var groups []DirGroup
+	for _, group := range GroupIdentical(dirs) {
+		placed := false
+		for idx := range groups {
+			if DirSimilarity(groups[idx].Members[0], group.Members[0]) >= threshold {
+				groups[idx].Members = append(groups[idx].Members, group.Members...)
+				groups[idx].Similar = true
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// DirSimilarity scores how alike the structures of a and b are, from 0 to 1,
+// as the weighted Jaccard index of the multisets that feed their signatures:
+// immediate files counted per extension, and subdirectory signatures. Equal
+// signatures score 1. Directories whose contents were not read, such as
+// leaves and unreadable directories, only match themselves exactly.
+func DirSimilarity(a, b *Directory) float64 {
+	if a.Signature != "" && a.Signature == b.Signature {
+		return 1
+	}
+	if !similarityComparable(a) || !similarityComparable(b) {
+		return 0
+	}
+
+	countsA := structureCounts(a)
+	countsB := structureCounts(b)
+	var shared, total int
+	for key, countA := range countsA {
+		countB := countsB[key]
+		shared += min(countA, countB)
+		total += max(countA, countB)
+	}
+	for key, countB := range countsB {
+		if _, seen := countsA[key]; !seen {
+			total += countB
+		}
+	}
+	if total == 0 {
+		return 1
+	}
+	return float64(shared) / float64(total)
+}
+
+func similarityComparable(dir *Directory) bool {
+	return dir.Err == nil && !dir.Leaf && !dir.Unexpanded && !dir.Ghost && dir.Package == nil
+}
+
+// structureCounts returns the multiset of dir's file extensions and
+// subdirectory signatures, with prefixes keeping the two kinds apart.
+func structureCounts(dir *Directory) map[string]int {
+	counts := make(map[string]int, len(dir.ExtCounts)+len(dir.Subdirs))
+	for ext, count := range dir.ExtCounts {
+		counts["f:"+ext] += count
+	}
+	for _, child := range dir.Subdirs {
+		counts["d:"+child.Signature]++
+	}
+	return counts
+}
+
+// similarity returns the GroupSimilar threshold for opts.
+func similarity(opts PrinterOptions) float64 {
+	if opts.Similarity <= 0 {
+		return 1
+	}
+	return opts.Similarity
+}
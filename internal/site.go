@@ -0,0 +1,163 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WriteSite writes a static HTML site for the tree into outDir: an
+// index.html for the root and one for every directory it lists, in a folder
+// hierarchy mirroring the tree. Each page shows the directory's entries with
+// the ordering and collapsing of the text tree, links every listed
+// subdirectory to its own page and carries a breadcrumb back to the root.
+// CSS is inlined, so the site needs no server or other assets. It returns
+// the number of pages written.
+func WriteSite(outDir, rootLabel string, dir *Directory, opts PrinterOptions) (int, error) {
+	if dir == nil {
+		return 0, fmt.Errorf("nil directory")
+	}
+	rootLabel, dir, opts, err := prepareTree(rootLabel, dir, opts)
+	if err != nil {
+		return 0, err
+	}
+	return writeSitePage(outDir, []string{rootLabel}, dir, opts)
+}
+
+// writeSitePage writes the page of dir into pageDir and recurses into the
+// subdirectories it links. crumbs holds the labels from the root down to dir.
+func writeSitePage(pageDir string, crumbs []string, dir *Directory, opts PrinterOptions) (int, error) {
+	if err := os.MkdirAll(pageDir, 0o755); err != nil {
+		return 0, err
+	}
+	items := buildItems(dir, opts)
+
+	f, err := os.Create(filepath.Join(pageDir, "index.html"))
+	if err != nil {
+		return 0, err
+	}
+	if err := writeSiteHTML(f, crumbs, dir, items); err != nil {
+		f.Close()
+		return 0, err
+	}
+	if err := f.Close(); err != nil {
+		return 0, err
+	}
+
+	pages := 1
+	for _, item := range items {
+		if item.kind != itemDir || item.dir.Err != nil {
+			continue
+		}
+		child := item.dir
+		written, err := writeSitePage(filepath.Join(pageDir, child.Name), append(crumbs[:len(crumbs):len(crumbs)], child.Name+"/"), child, opts)
+		pages += written
+		if err != nil {
+			return pages, err
+		}
+	}
+	return pages, nil
+}
+
+func writeSiteHTML(w io.Writer, crumbs []string, dir *Directory, items []treeItem) error {
+	bw := bufio.NewWriter(w)
+	title := strings.Join(crumbs, " › ")
+	fmt.Fprintln(bw, "<!DOCTYPE html>")
+	fmt.Fprintln(bw, `<html lang="en">`)
+	fmt.Fprintln(bw, "<head>")
+	fmt.Fprintln(bw, `<meta charset="utf-8">`)
+	fmt.Fprintf(bw, "<title>%s</title>\n", html.EscapeString(title))
+	fmt.Fprintln(bw, "<style>")
+	fmt.Fprintln(bw, "body { font-family: ui-monospace, monospace; margin: 1.5em; }")
+	fmt.Fprintln(bw, "a { color: inherit; }")
+	fmt.Fprintln(bw, ".tp-crumbs { color: #6b7280; margin-bottom: 1em; }")
+	fmt.Fprintln(bw, ".tp-stats { color: #6b7280; font-weight: normal; }")
+	fmt.Fprintln(bw, ".tp-tree { list-style: none; padding-left: 1.2em; }")
+	fmt.Fprintln(bw, ".tp-dir { color: #1d4ed8; }")
+	fmt.Fprintln(bw, ".tp-file { color: #111827; }")
+	fmt.Fprintln(bw, ".tp-error { color: #b91c1c; }")
+	fmt.Fprintln(bw, ".tp-summary { color: #6b7280; font-style: italic; }")
+	fmt.Fprintln(bw, "</style>")
+	fmt.Fprintln(bw, "</head>")
+	fmt.Fprintln(bw, "<body>")
+
+	fmt.Fprint(bw, `<nav class="tp-crumbs">`)
+	for idx, crumb := range crumbs {
+		if idx > 0 {
+			fmt.Fprint(bw, " › ")
+		}
+		if idx == len(crumbs)-1 {
+			fmt.Fprint(bw, html.EscapeString(crumb))
+			continue
+		}
+		// Every crumb below the root is a name plus '/', one folder per
+		// slash.
+		up := 0
+		for _, below := range crumbs[idx+1:] {
+			up += strings.Count(filepath.ToSlash(below), "/")
+		}
+		fmt.Fprintf(bw, "<a href=\"%sindex.html\">%s</a>", strings.Repeat("../", up), html.EscapeString(crumb))
+	}
+	fmt.Fprintln(bw, "</nav>")
+	fmt.Fprintf(
+		bw,
+		"<h1 class=\"tp-dir\">%s <span class=\"tp-stats\">[%d subdirectories, %d files]</span></h1>\n",
+		html.EscapeString(crumbs[len(crumbs)-1]),
+		dir.TotalDirs,
+		dir.TotalFiles,
+	)
+
+	fmt.Fprintln(bw, `<ul class="tp-tree">`)
+	for _, item := range items {
+		switch item.kind {
+		case itemDir:
+			child := item.dir
+			if child.Err != nil {
+				fmt.Fprintf(
+					bw,
+					"<li class=\"tp-dir tp-error\">%s/ <span class=\"tp-error\">[%s]</span></li>\n",
+					html.EscapeString(child.Name),
+					html.EscapeString(errorText(child)),
+				)
+				continue
+			}
+			fmt.Fprintf(
+				bw,
+				"<li class=\"tp-dir\"><a href=\"%s/index.html\">%s/</a> <span class=\"tp-stats\">(%d dirs, %d files)</span></li>\n",
+				sitePageHref(child.Name),
+				html.EscapeString(child.Name),
+				child.TotalDirs,
+				child.TotalFiles,
+			)
+		case itemFile:
+			fmt.Fprintf(bw, "<li class=\"tp-file\">%s</li>\n", html.EscapeString(item.file.Name))
+		case itemDateGroup:
+			fmt.Fprintf(bw, "<li class=\"tp-summary\">%s</li>\n", html.EscapeString(itemSummary(dir, item)))
+			for _, file := range item.dateGroup.files {
+				fmt.Fprintf(bw, "<li class=\"tp-file\">%s</li>\n", html.EscapeString(file.Name))
+			}
+		default:
+			fmt.Fprintf(bw, "<li class=\"tp-summary\">%s</li>\n", html.EscapeString(itemSummary(dir, item)))
+		}
+	}
+	fmt.Fprintln(bw, "</ul>")
+	fmt.Fprintln(bw, "</body>")
+	fmt.Fprintln(bw, "</html>")
+	return bw.Flush()
+}
+
+// sitePageHref returns the relative link to the page of a child directory.
+// Names holding separators, such as roots renamed by MergeRoots, map to
+// nested folders, so each segment is escaped on its own.
+func sitePageHref(name string) string {
+	segments := strings.Split(filepath.ToSlash(name), "/")
+	for idx, segment := range segments {
+		segments[idx] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
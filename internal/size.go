@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FormatSize renders a byte count in a compact human-readable form such as
+// "512", "1.2K" or "3.4M", using powers of 1024.
+func FormatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d", bytes)
+	}
+	value := float64(bytes) / unit
+	suffixes := "KMGTPE"
+	idx := 0
+	for value >= unit && idx < len(suffixes)-1 {
+		value /= unit
+		idx++
+	}
+	return fmt.Sprintf("%.1f%c", value, suffixes[idx])
+}
+
+// ParseSize parses a byte count written as a plain number or with a K, M, G,
+// T, P or E suffix (powers of 1024, case-insensitive, optional trailing "B"),
+// such as "512", "10K" or "1.5MB".
+func ParseSize(text string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(text))
+	s = strings.TrimSuffix(s, "B")
+	multiplier := 1.0
+	if n := len(s); n > 0 {
+		if idx := strings.IndexByte("KMGTPE", s[n-1]); idx >= 0 {
+			for i := 0; i <= idx; i++ {
+				multiplier *= 1024
+			}
+			s = s[:n-1]
+		}
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q", text)
+	}
+	return int64(value * multiplier), nil
+}
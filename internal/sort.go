@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"io/fs"
+	"slices"
+	"sort"
+	"time"
+)
+
+// sortEntries orders the entries of a directory before they are walked, so
+// MaxFiles keeps the first files in that order. SortSize and SortMtime put
+// the largest and newest entries first and break ties by name, keeping the
+// order deterministic; entries whose info cannot be read count as zero.
+// Directories are re-sorted by sortSubdirs once their sizes are known.
+func sortEntries(entries []fs.DirEntry, sortBy string, reverse bool) {
+	switch sortBy {
+	case SortNone:
+	case SortSize, SortMtime:
+		keys := make(map[string]int64, len(entries))
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			if sortBy == SortSize {
+				keys[entry.Name()] = info.Size()
+			} else {
+				keys[entry.Name()] = info.ModTime().UnixNano()
+			}
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			a, b := entries[i].Name(), entries[j].Name()
+			if keys[a] != keys[b] {
+				return keys[a] > keys[b]
+			}
+			return a < b
+		})
+	default:
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Name() < entries[j].Name()
+		})
+	}
+	if reverse {
+		slices.Reverse(entries)
+	}
+}
+
+// sortSubdirs reorders walked subdirectories by their recursive size for
+// SortSize, which the directory entries themselves do not carry. Other
+// orders were already applied by sortEntries.
+func sortSubdirs(subdirs []*Directory, sortBy string, reverse bool) {
+	if sortBy != SortSize {
+		return
+	}
+	sort.Slice(subdirs, func(i, j int) bool {
+		a, b := subdirs[i], subdirs[j]
+		if a.TotalSize != b.TotalSize {
+			return (a.TotalSize > b.TotalSize) != reverse
+		}
+		return (a.Name < b.Name) != reverse
+	})
+}
+
+// sortKey holds what the walk orders an entry by, for merging a directory's
+// subdirectories and files when PrinterOptions.DirsFirst is off.
+type sortKey struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func dirSortKey(dir *Directory) sortKey {
+	return sortKey{name: dir.Name, size: dir.TotalSize, modTime: dir.ModTime}
+}
+
+func fileSortKey(file FileEntry) sortKey {
+	return sortKey{name: file.Name, size: file.Size, modTime: file.ModTime}
+}
+
+// sortsBefore reports whether a strictly precedes b in the order the
+// subdirectories and files were each listed in: the walk's SortBy and
+// Reverse order, regrouped by section or by size when the printer does so.
+func (opts PrinterOptions) sortsBefore(a, b sortKey) bool {
+	if opts.SectionChars > 0 {
+		ka := sectionKey(a.name, opts.SectionChars, opts.SectionCaseSensitive)
+		kb := sectionKey(b.name, opts.SectionChars, opts.SectionCaseSensitive)
+		if ka != kb {
+			return ka < kb
+		}
+	}
+	if opts.DiskUsageSort {
+		return a.size > b.size
+	}
+	if opts.Reverse {
+		a, b = b, a
+	}
+	switch opts.SortBy {
+	case SortSize:
+		if a.size != b.size {
+			return a.size > b.size
+		}
+	case SortMtime:
+		if !a.modTime.Equal(b.modTime) {
+			return a.modTime.After(b.modTime)
+		}
+	}
+	return a.name < b.name
+}
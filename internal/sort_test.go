@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDirsFirst(t *testing.T) {
+	root := writeTree(t, "a.txt", "b/x.txt", "c.txt", "d/y.md")
+	tests := []struct {
+		name string
+		opts Options
+		pr   PrinterOptions
+		want string
+	}{
+		{"dirs first", Options{}, PrinterOptions{DirsFirst: true}, "b/ x.txt d/ y.md a.txt c.txt"},
+		{"mixed", Options{}, PrinterOptions{}, "a.txt b/ x.txt c.txt d/ y.md"},
+		{"mixed reversed", Options{Reverse: true}, PrinterOptions{Reverse: true}, "d/ y.md c.txt b/ x.txt a.txt"},
+		{"unsorted", Options{SortBy: SortNone}, PrinterOptions{SortBy: SortNone}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := Walk(root, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.pr.NoReport = true
+			var names []string
+			for _, line := range strings.Split(strings.TrimSpace(render(t, dir, tt.pr)), "\n")[1:] {
+				fields := strings.Fields(line)
+				names = append(names, fields[len(fields)-1])
+			}
+			got := strings.Join(names, " ")
+			if tt.want == "" {
+				// Without a common order directories stay first.
+				if !strings.HasSuffix(names[0], "/") {
+					t.Errorf("unsorted order %q does not keep directories first", got)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("order = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package internal
+
+import "io/fs"
+
+// Markers for special files, recorded in FileEntry.Special.
+const (
+	SpecialFIFO   = "fifo"
+	SpecialSocket = "socket"
+	SpecialDevice = "device"
+)
+
+// specialKind classifies FIFOs, sockets and device files from the type bits
+// of a directory entry, returning "" for regular files and symlinks. It never
+// touches the file itself, so it is safe on entries that would block.
+func specialKind(mode fs.FileMode) string {
+	switch {
+	case mode&fs.ModeNamedPipe != 0:
+		return SpecialFIFO
+	case mode&fs.ModeSocket != 0:
+		return SpecialSocket
+	case mode&fs.ModeDevice != 0:
+		return SpecialDevice
+	}
+	return ""
+}
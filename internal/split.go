@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SplitResult describes one subtree written by WriteSplit.
+type SplitResult struct {
+	RelPath  string
+	FileName string
+}
+
+// WriteSplit renders every directory found splitLevel levels below root into
+// its own file inside outDir, then writes an index.txt listing them. Files are
+// named after the subdirectory's path relative to root, with separators
+// replaced by "__" so nested splits cannot collide. Color and any anchor are
+// always disabled.
+func WriteSplit(outDir string, root *Directory, splitLevel int, opts PrinterOptions) ([]SplitResult, error) {
+	if root == nil {
+		return nil, fmt.Errorf("nil directory")
+	}
+	if splitLevel < 1 {
+		splitLevel = 1
+	}
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	opts.UseColor = false
+	opts.Anchor = ""
+	targets := collectAtDepth(root, root.Level+splitLevel)
+	results := make([]SplitResult, 0, len(targets))
+	for _, dir := range targets {
+		rel, err := filepath.Rel(root.Path, dir.Path)
+		if err != nil {
+			rel = dir.Name
+		}
+		fileName := strings.ReplaceAll(filepath.ToSlash(rel), "/", "__") + ".txt"
+		label := rel + string(os.PathSeparator)
+		if err := writeTreeFile(filepath.Join(outDir, fileName), label, dir, opts); err != nil {
+			return results, err
+		}
+		results = append(results, SplitResult{RelPath: rel, FileName: fileName})
+	}
+
+	if err := writeSplitIndex(filepath.Join(outDir, "index.txt"), results); err != nil {
+		return results, err
+	}
+	return results, nil
+}
+
+func collectAtDepth(dir *Directory, level int) []*Directory {
+	if dir.Level == level {
+		return []*Directory{dir}
+	}
+	var found []*Directory
+	for _, child := range dir.Subdirs {
+		found = append(found, collectAtDepth(child, level)...)
+	}
+	return found
+}
+
+func writeTreeFile(path, label string, dir *Directory, opts PrinterOptions) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	opts.Writer = f
+	if err := PrintTree(label, dir, opts); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeSplitIndex(path string, results []SplitResult) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, r := range results {
+		fmt.Fprintf(w, "%s%c\t%s\n", r.RelPath, os.PathSeparator, r.FileName)
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"unicode/utf8"
+)
+
+// Defaults for FormatSVG when PrinterOptions leaves the font unset.
+const (
+	defaultSVGFont     = "monospace"
+	defaultSVGFontSize = 14
+)
+
+// svgRow is one line of the SVG layout. parent is the index of the row the
+// connector is drawn from, or -1 for top-level rows.
+type svgRow struct {
+	depth  int
+	text   string
+	class  string
+	parent int
+}
+
+// renderSVG lays the tree out as a self-contained SVG image: one text row
+// per line of the text tree, indented by depth, with connector lines drawn
+// from each directory to its entries. Rows carry the tp-dir, tp-file,
+// tp-error and tp-summary classes, colored from an embedded stylesheet unless
+// UseColor is off. Glyph widths are estimated for a monospace font.
+func renderSVG(w io.Writer, rootLabel string, dir *Directory, opts PrinterOptions) error {
+	font := opts.SVGFont
+	if font == "" {
+		font = defaultSVGFont
+	}
+	size := float64(opts.SVGFontSize)
+	if size <= 0 {
+		size = defaultSVGFontSize
+	}
+
+	var rows []svgRow
+	parent, depth := -1, 0
+	if !opts.NoRoot {
+		rows = append(rows, svgRow{text: rootLabel, class: "tp-dir", parent: -1})
+		parent, depth = 0, 1
+	}
+	if dir.Err != nil {
+		rows = append(rows, svgRow{depth: depth, text: "[" + errorText(dir) + "]", class: "tp-error", parent: parent})
+	} else {
+		rows = appendSVGRows(rows, dir, parent, depth, opts)
+	}
+
+	lineHeight := size * 1.5
+	indent := size * 1.5
+	margin := size
+	charWidth := size * 0.6
+	x := func(row svgRow) float64 { return margin + float64(row.depth)*indent }
+	y := func(idx int) float64 { return margin + float64(idx)*lineHeight + size }
+
+	width := 0.0
+	for _, row := range rows {
+		width = max(width, x(row)+float64(utf8.RuneCountInString(row.text))*charWidth)
+	}
+	height := margin*2 + float64(len(rows))*lineHeight
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%.0f\" height=\"%.0f\" viewBox=\"0 0 %.0f %.0f\" font-family=\"%s\" font-size=\"%g\">\n",
+		width+margin, height, width+margin, height, html.EscapeString(font), size)
+	fmt.Fprintln(bw, "  <style>")
+	if opts.UseColor {
+		fmt.Fprintln(bw, "    .tp-dir { fill: #1d4ed8; font-weight: bold; }")
+		fmt.Fprintln(bw, "    .tp-file { fill: #111827; }")
+		fmt.Fprintln(bw, "    .tp-error { fill: #b91c1c; }")
+		fmt.Fprintln(bw, "    .tp-summary { fill: #6b7280; font-style: italic; }")
+	} else {
+		fmt.Fprintln(bw, "    .tp-dir { font-weight: bold; }")
+		fmt.Fprintln(bw, "    .tp-summary { font-style: italic; }")
+	}
+	fmt.Fprintln(bw, "  </style>")
+	fmt.Fprintf(bw, "  <rect width=\"100%%\" height=\"100%%\" fill=\"#ffffff\"/>\n")
+
+	// One vertical line per parent, from just below its row down to its
+	// last child, plus a short horizontal tick into every child.
+	lastChild := make(map[int]int)
+	for idx, row := range rows {
+		if row.parent >= 0 {
+			lastChild[row.parent] = idx
+		}
+	}
+	fmt.Fprintln(bw, `  <g stroke="#9ca3af" stroke-width="1" fill="none">`)
+	for idx, row := range rows {
+		last, ok := lastChild[idx]
+		if !ok {
+			continue
+		}
+		vx := x(row) + charWidth/2
+		fmt.Fprintf(bw, "    <path d=\"M%.1f %.1fV%.1f\"/>\n", vx, y(idx)+size*0.3, y(last)-size*0.35)
+	}
+	for idx, row := range rows {
+		if row.parent < 0 {
+			continue
+		}
+		vx := x(rows[row.parent]) + charWidth/2
+		fmt.Fprintf(bw, "    <path d=\"M%.1f %.1fH%.1f\"/>\n", vx, y(idx)-size*0.35, x(row)-charWidth/2)
+	}
+	fmt.Fprintln(bw, "  </g>")
+
+	for idx, row := range rows {
+		fmt.Fprintf(bw, "  <text x=\"%.1f\" y=\"%.1f\" class=\"%s\">%s</text>\n", x(row), y(idx), row.class, html.EscapeString(row.text))
+	}
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+func appendSVGRows(rows []svgRow, dir *Directory, parent, depth int, opts PrinterOptions) []svgRow {
+	for _, item := range buildItems(dir, opts) {
+		switch item.kind {
+		case itemDir:
+			child := item.dir
+			if child.Err != nil {
+				rows = append(rows, svgRow{depth: depth, text: child.Name + "/ [" + errorText(child) + "]", class: "tp-error", parent: parent})
+				continue
+			}
+			rows = append(rows, svgRow{depth: depth, text: child.Name + "/", class: "tp-dir", parent: parent})
+			rows = appendSVGRows(rows, child, len(rows)-1, depth+1, opts)
+		case itemFile:
+			rows = append(rows, svgRow{depth: depth, text: item.file.Name, class: "tp-file", parent: parent})
+		case itemDateGroup:
+			rows = append(rows, svgRow{depth: depth, text: itemSummary(dir, item), class: "tp-summary", parent: parent})
+			group := len(rows) - 1
+			for _, file := range item.dateGroup.files {
+				rows = append(rows, svgRow{depth: depth + 1, text: file.Name, class: "tp-file", parent: group})
+			}
+		default:
+			rows = append(rows, svgRow{depth: depth, text: itemSummary(dir, item), class: "tp-summary", parent: parent})
+		}
+	}
+	return rows
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package internal
+
+import "os"
+
+// TerminalHeight is not supported on this platform, so --autofit relies on
+// the LINES environment variable alone.
+func TerminalHeight(f *os.File) int {
+	return 0
+}
+
+// IsTerminal reports whether f is a character device, the closest check
+// available without terminal ioctls.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
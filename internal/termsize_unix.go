@@ -0,0 +1,25 @@
+//go:build unix
+
+package internal
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// TerminalHeight returns the number of rows of the terminal f is attached
+// to, or 0 when f is not a terminal.
+func TerminalHeight(f *os.File) int {
+	size, err := unix.IoctlGetWinsize(int(f.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		return 0
+	}
+	return int(size.Row)
+}
+
+// IsTerminal reports whether f is attached to a terminal.
+func IsTerminal(f *os.File) bool {
+	_, err := unix.IoctlGetWinsize(int(f.Fd()), unix.TIOCGWINSZ)
+	return err == nil
+}
@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"text/tabwriter"
+)
+
+// TopLevelStat aggregates the recursive totals of one immediate subdirectory
+// of the root.
+type TopLevelStat struct {
+	Name  string
+	Dirs  int
+	Files int
+	Size  int64
+	Err   error
+}
+
+// TopLevelStats returns the totals of each immediate subdirectory of root,
+// ordered by descending file count and then by name.
+func TopLevelStats(root *Directory) []TopLevelStat {
+	stats := make([]TopLevelStat, 0, len(root.Subdirs))
+	for _, child := range root.Subdirs {
+		stats = append(stats, TopLevelStat{
+			Name:  child.Name,
+			Dirs:  child.TotalDirs,
+			Files: child.TotalFiles,
+			Size:  child.TotalSize,
+			Err:   child.Err,
+		})
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		if stats[i].Files != stats[j].Files {
+			return stats[i].Files > stats[j].Files
+		}
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
+
+// PrintTopLevel writes stats as an aligned, uncolored table.
+func PrintTopLevel(w io.Writer, stats []TopLevelStat) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DIRECTORY\tFILES\tDIRS\tSIZE")
+	for _, stat := range stats {
+		name := stat.Name + "/"
+		if stat.Err != nil {
+			name += fmt.Sprintf(" [%s]", stat.Err)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\n", name, stat.Files, stat.Dirs, FormatSize(stat.Size))
+	}
+	return tw.Flush()
+}
+
+type jsonTopLevel struct {
+	Name  string `json:"name"`
+	Dirs  int    `json:"dirs"`
+	Files int    `json:"files"`
+	Size  int64  `json:"size"`
+	Error string `json:"error,omitempty"`
+}
+
+// PrintTopLevelJSON writes stats as a JSON array of objects, one per
+// directory in the order given, with sizes in bytes.
+func PrintTopLevelJSON(w io.Writer, stats []TopLevelStat) error {
+	rows := make([]jsonTopLevel, 0, len(stats))
+	for _, stat := range stats {
+		row := jsonTopLevel{Name: stat.Name, Dirs: stat.Dirs, Files: stat.Files, Size: stat.Size}
+		if stat.Err != nil {
+			row.Error = stat.Err.Error()
+		}
+		rows = append(rows, row)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rows)
+}
+
+// PrintOneline writes one terse line per stat, such as
+// "src/  (42 dirs, 310 files, 4.2M)", ordered by name or, with bySize, by
+// descending size and then by name.
+func PrintOneline(w io.Writer, stats []TopLevelStat, bySize bool) error {
+	sorted := slices.Clone(stats)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if bySize && sorted[i].Size != sorted[j].Size {
+			return sorted[i].Size > sorted[j].Size
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, stat := range sorted {
+		if stat.Err != nil {
+			fmt.Fprintf(tw, "%s/\t[%s]\n", stat.Name, stat.Err)
+			continue
+		}
+		fmt.Fprintf(tw, "%s/\t(%d dirs, %d files, %s)\n", stat.Name, stat.Dirs, stat.Files, FormatSize(stat.Size))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,29 @@
+package internal
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPrintTopLevelJSON(t *testing.T) {
+	dir := BuildFromPaths("root", []string{"a/one.go", "b/x/two.go", "b/three.go", "c/"})
+	var out strings.Builder
+	if err := PrintTopLevelJSON(&out, TopLevelStats(dir)); err != nil {
+		t.Fatal(err)
+	}
+
+	var rows []map[string]any
+	if err := json.Unmarshal([]byte(out.String()), &rows); err != nil {
+		t.Fatalf("output is not a JSON array: %v\n%s", err, out.String())
+	}
+	want := []map[string]any{
+		{"name": "b", "dirs": 1.0, "files": 2.0, "size": 0.0},
+		{"name": "a", "dirs": 0.0, "files": 1.0, "size": 0.0},
+		{"name": "c", "dirs": 0.0, "files": 0.0, "size": 0.0},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+}
@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// renderURLs writes one URL per file, joining opts.URLBase with the file's
+// path relative to the root. Each path segment is percent-escaped. With
+// opts.URLDirs, every directory also emits its index URL (ending in '/')
+// before its contents. Files dropped by MaxFiles truncation are not listed,
+// so pair this format with an unlimited --files for a complete manifest.
+func renderURLs(w io.Writer, dir *Directory, opts PrinterOptions) error {
+	base := strings.TrimSuffix(opts.URLBase, "/") + "/"
+	bw := bufio.NewWriter(w)
+	writeURLs(bw, dir, base, nil, opts)
+	return bw.Flush()
+}
+
+func writeURLs(w io.Writer, dir *Directory, base string, segments []string, opts PrinterOptions) {
+	if dir.Err != nil {
+		return
+	}
+	if opts.URLDirs {
+		index := joinURLSegments(segments)
+		if index != "" {
+			index += "/"
+		}
+		fmt.Fprintln(w, base+index)
+	}
+	if opts.MaxDepth > 0 && len(segments) >= opts.MaxDepth {
+		return
+	}
+	for _, file := range dir.Files {
+		if file.Ghost {
+			continue
+		}
+		fmt.Fprintln(w, base+joinURLSegments(append(segments, file.Name)))
+	}
+	for _, child := range dir.Subdirs {
+		if child.Ghost {
+			continue
+		}
+		writeURLs(w, child, base, append(segments[:len(segments):len(segments)], child.Name), opts)
+	}
+}
+
+func joinURLSegments(segments []string) string {
+	escaped := make([]string, len(segments))
+	for idx, segment := range segments {
+		escaped[idx] = url.PathEscape(segment)
+	}
+	return strings.Join(escaped, "/")
+}
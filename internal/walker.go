@@ -1,24 +1,152 @@
 package internal
 
 import (
+	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"hash"
 	"hash/fnv"
 	"io/fs"
 	"math"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Options controls how the filesystem is traversed.
 type Options struct {
 	MaxFiles int
 	MaxLevel int
+	// SortBy selects the entry order: SortName (the default when empty),
+	// SortSize (largest first, directories by recursive size), SortMtime
+	// (newest first) or SortNone, which keeps the order the filesystem
+	// returned entries in. Readdir order is not guaranteed to be stable
+	// across runs or platforms. Reverse inverts the order.
+	SortBy  string
+	Reverse bool
+	// SkipOver, when positive, stops expansion of any directory holding more
+	// than this many immediate entries. Such directories are marked
+	// Unexpanded and only their immediate counts are recorded. The root is
+	// always expanded.
+	SkipOver int
+	// SampleFiles, when positive, replaces MaxFiles truncation: each directory
+	// shows a reproducible random sample of this many files, chosen with Seed.
+	SampleFiles int
+	Seed        int64
+	// OneFilesystem stops the walk from descending into directories that
+	// live on a different device than the root, like find -xdev. It has no
+	// effect on platforms without device ids.
+	OneFilesystem bool
+	// DirsOnly keeps no FileEntry values: every file is counted in
+	// HiddenFiles as if truncated, so counts, totals and signatures are
+	// those of a full walk.
+	DirsOnly bool
+	// ShowHidden lists entries whose name starts with '.'. Without it they
+	// are filtered like excluded files, and hidden directories are never
+	// read. This is unrelated to Directory.HiddenFiles, which counts files
+	// truncated by MaxFiles.
+	ShowHidden bool
+	// IncludePatterns, when not empty, keeps only the files whose base name
+	// matches one of these filepath.Match globs. ExcludePatterns drops files
+	// matching any of its globs, even included ones. Directories are never
+	// filtered by either.
+	IncludePatterns []string
+	ExcludePatterns []string
+	// ShowOnlyExcluded inverts ExcludePatterns: only matching files are kept
+	// (among the included ones) and directories left without any kept file
+	// are pruned.
+	ShowOnlyExcluded bool
+	// HideSizeOver, when positive, keeps files larger than this many bytes
+	// out of Files like MaxFiles truncation does: they are reported through
+	// HiddenFiles but still count toward every total.
+	HideSizeOver int64
+	// SkipSpecial drops FIFOs, sockets and device files instead of listing
+	// them with a marker. Either way they are never opened or sized.
+	SkipSpecial bool
+	// SignatureFunc, if set, replaces DefaultSignature as the signature of
+	// every fully walked directory, which is what GroupIdentical keys on. It
+	// is called once per directory after all of its fields are filled in,
+	// including the Signature of each subdirectory, so it can combine those
+	// bottom-up. It must be deterministic and must not depend on the order of
+	// Files or Subdirs. Files only holds the entries kept after MaxFiles
+	// truncation; use MaxFiles 0 when the hook looks at file names. Leaf and
+	// unreadable directories keep their built-in, path-unique signatures.
+	SignatureFunc func(dir *Directory) string
+	// StopAt lists filepath.Match globs; directories below the root whose
+	// name matches are kept as unread leaves, so the walk never descends
+	// into them.
+	StopAt []string
+	// PreviewLines, when positive, reads the first lines of every listed text
+	// file no larger than PreviewMaxSize bytes (64 KiB when zero) into
+	// FileEntry.Preview. Files hidden by truncation are never read.
+	PreviewLines   int
+	PreviewMaxSize int64
+	// ExpandArchives lists .zip, .tar, .tar.gz and .tgz files as directories
+	// of their contents, marked Archive, instead of as files. Unreadable
+	// archives stay plain files.
+	ExpandArchives bool
+	// SignatureDepth, when positive, stops signatures from looking deeper
+	// than this level: directories at level SignatureDepth or below hash only
+	// their own files and the number of their subdirectories, so everything
+	// beneath them is opaque. Cheaper on deep trees, but two directories whose
+	// contents differ only below the threshold then collapse as identical.
+	// SignatureFunc, when set, takes precedence.
+	SignatureDepth int
+	// PackageManifests lists file names, such as DefaultPackageManifests,
+	// that mark their directory as a package root. The first listed manifest
+	// present is parsed into Directory.Package.
+	PackageManifests []string
+	// ContentPattern, when set, keeps only the files with at least one line
+	// it matches, counting the rest as filtered, and records the number of
+	// matching lines in FileEntry.Matches. Binary files and files larger
+	// than ContentMaxSize bytes (1 MiB when zero) are never searched.
+	ContentPattern *regexp.Regexp
+	ContentMaxSize int64
+	// UseGitignore skips the entries matched by the .gitignore files met
+	// during the walk. Each directory's file adds to the rules inherited from
+	// its parents. Ignored entries count as filtered; ignored directories
+	// are never read.
+	UseGitignore bool
+	// FollowSymlinks walks into symbolic links to directories as if they
+	// were directories. A link back to a directory being walked is kept as
+	// a SymlinkCycle leaf instead. Without it, such links are unread leaves.
+	// Either way the link counts as a directory and a symlink.
+	FollowSymlinks bool
+	// Concurrency bounds how many directories are read at once: 0 means
+	// GOMAXPROCS and 1 walks serially. The resulting tree is identical
+	// either way. A SignatureFunc must be safe for concurrent use.
+	Concurrency int
+
+	rootDevice    uint64
+	hasRootDevice bool
+	// ignores holds the .gitignore rules in effect for the directory being
+	// walked.
+	ignores []ignoreRule
+	// ancestors holds the resolved paths of the directories from the root
+	// down to the one being walked when FollowSymlinks is set.
+	ancestors []string
+	// workers holds one token per subdirectory walk running on its own
+	// goroutine; nil when walking serially.
+	workers chan struct{}
+	// ctx is checked before each directory is read; see WalkContext.
+	ctx context.Context
 }
 
+// Supported values for Options.SortBy.
+const (
+	SortName  = "name"
+	SortSize  = "size"
+	SortMtime = "mtime"
+	SortNone  = "none"
+)
+
 // Directory represents a directory and its contents used for rendering.
 type Directory struct {
 	Name               string
@@ -31,18 +159,99 @@ type Directory struct {
 	ImmediateFileCount int
 	TotalDirs          int
 	TotalFiles         int
-	Signature          string
-	Err                error
+	// ModTime is the directory's modification time, captured before its
+	// entries were read. It is zero for directories that were not walked.
+	ModTime time.Time
+	// TotalSize is the cumulative size in bytes of every file below the
+	// directory, including files hidden by MaxFiles truncation.
+	TotalSize int64
+	// TotalSymlinks counts the symbolic links below the directory (they are
+	// also counted as files), and TotalErrors the directories below it that
+	// could not be read.
+	TotalSymlinks int
+	TotalErrors   int
+	Signature     string
+	Err           error
+	// Unexpanded is set when the directory exceeded Options.SkipOver. Its
+	// EntryCount is exact, but Subdirs and Files are left empty and the
+	// totals only cover its immediate entries.
+	Unexpanded bool
+	EntryCount int
+	// Leaf is set for directories whose contents were deliberately not read:
+	// those below MaxLevel, mount points skipped by OneFilesystem and
+	// symbolic links that are not followed.
+	Leaf bool
+	// OtherFilesystem marks a mount point skipped by Options.OneFilesystem.
+	OtherFilesystem bool
+	// StoppedAt marks a directory left unread because its name matched
+	// Options.StopAt.
+	StoppedAt bool
+	// LinkTarget holds the target of a directory reached through a symbolic
+	// link, as written in the link. SymlinkCycle marks such a link left
+	// unread because it leads back to a directory being walked.
+	LinkTarget   string
+	SymlinkCycle bool
+	// Archive marks the top of an archive listed by Options.ExpandArchives.
+	Archive bool
+	// TimedOut marks a directory left unread because the WalkContext
+	// context was done before the walk reached it.
+	TimedOut bool
+	// HasReadme reports whether the directory holds a README file.
+	HasReadme bool
+	// Package is set for directories holding one of
+	// Options.PackageManifests.
+	Package *PackageInfo
+	// Expected and Ghost are set by MarkExpected: Expected for directories
+	// listed in the manifest, Ghost for listed directories that do not exist.
+	Expected bool
+	Ghost    bool
+	// FilteredEntries counts the immediate entries dropped by walk filters.
+	// Filtered entries are excluded from every other count.
+	FilteredEntries int
+	// Sampled is set when Files holds a random sample rather than the first
+	// files in walk order.
+	Sampled bool
+	// ExtCounts maps lowercased file extensions ("<noext>" for none) to the
+	// number of immediate files carrying them, including truncated files.
+	ExtCounts map[string]int
+	// ExtSpellings records, per lowercased extension, how often each original
+	// spelling (e.g. ".JPG" vs ".jpg") was encountered.
+	ExtSpellings map[string]map[string]int
+	// ExtSizes maps lowercased file extensions to the total bytes of the
+	// immediate files carrying them.
+	ExtSizes map[string]int64
 }
 
 // FileEntry captures the metadata required to render a file node.
 type FileEntry struct {
-	Name string
+	Name    string
+	Size    int64
+	ModTime time.Time
+	// Special is SpecialFIFO, SpecialSocket or SpecialDevice for special
+	// files, whose Size and ModTime are left zero.
+	Special string
+	// Preview holds the first Options.PreviewLines lines of small text files;
+	// PreviewTruncated is set when the file has more.
+	Preview          []string
+	PreviewTruncated bool
+	// Matches is the number of lines Options.ContentPattern matched.
+	Matches int
+	// Expected and Ghost are set by MarkExpected: Expected for files listed
+	// in the manifest, Ghost for listed files that do not exist.
+	Expected bool
+	Ghost    bool
 }
 
 // Walk builds a Directory tree starting at the provided path according to the
 // supplied options. Returns an error if the root path is inaccessible.
 func Walk(path string, opts Options) (*Directory, error) {
+	return WalkContext(context.Background(), path, opts)
+}
+
+// WalkContext is Walk with cancellation. Once ctx is done, directories not
+// yet read are kept as TimedOut leaves, and the partial tree is returned
+// together with ctx.Err() so callers can still render it.
+func WalkContext(ctx context.Context, path string, opts Options) (*Directory, error) {
 	info, err := os.Stat(path)
 	if err != nil {
 		return nil, err
@@ -51,12 +260,36 @@ func Walk(path string, opts Options) (*Directory, error) {
 		return nil, fmt.Errorf("%s is not a directory", path)
 	}
 
+	if opts.OneFilesystem {
+		opts.rootDevice, opts.hasRootDevice = deviceID(info)
+	}
+
+	opts.ctx = ctx
+	if opts.FollowSymlinks {
+		real, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return nil, err
+		}
+		if real, err = filepath.Abs(real); err != nil {
+			return nil, err
+		}
+		opts.ancestors = []string{real}
+	}
+	workers := opts.Concurrency
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > 1 {
+		// The calling goroutine is one of the workers.
+		opts.workers = make(chan struct{}, workers-1)
+	}
 	clean := filepath.Clean(path)
 	root := walkDir(clean, info.Name(), 0, opts)
+	root.ModTime = info.ModTime()
 	if root.Err != nil {
 		return nil, root.Err
 	}
-	return root, nil
+	return root, ctx.Err()
 }
 
 func walkDir(path, name string, level int, opts Options) *Directory {
@@ -66,60 +299,274 @@ func walkDir(path, name string, level int, opts Options) *Directory {
 		Level: level,
 	}
 
-	entries, err := os.ReadDir(path)
+	if opts.ctx != nil && opts.ctx.Err() != nil {
+		node.Leaf = true
+		node.TimedOut = true
+		node.Signature = signatureForLeaf(path)
+		return node
+	}
+
+	entries, err := readDirUnsorted(path)
 	if err != nil {
 		node.Err = err
 		node.Signature = signatureForError(path, err)
 		return node
 	}
 
-	sort.Slice(entries, func(i, j int) bool {
-		return entries[i].Name() < entries[j].Name()
-	})
+	if opts.UseGitignore {
+		opts.ignores = loadGitignore(path, opts.ignores)
+	}
+
+	node.EntryCount = len(entries)
+	if opts.SkipOver > 0 && len(entries) > opts.SkipOver && level > 0 {
+		markUnexpanded(node, entries)
+		return node
+	}
+
+	sortEntries(entries, opts.SortBy, opts.Reverse)
 
 	maxFiles := opts.MaxFiles
 	if maxFiles <= 0 {
 		maxFiles = math.MaxInt
 	}
+	if opts.DirsOnly {
+		// Files are still counted, just never kept.
+		maxFiles = 0
+	}
 
 	fileExtCounts := map[string]int{}
+	extSpellings := map[string]map[string]int{}
+	extSizes := map[string]int64{}
 	hiddenFiles := 0
+	var immediateSize int64
+	symlinks := 0
 	files := make([]FileEntry, 0, len(entries))
 	subdirs := make([]*Directory, 0)
+	var sampler *fileSampler
+	if opts.SampleFiles > 0 && !opts.DirsOnly {
+		sampler = newFileSampler(opts.SampleFiles, opts.Seed, path)
+	}
+	// pending tracks the subdirectories being walked on other goroutines;
+	// they fill in the placeholders already in subdirs, so the order and
+	// everything derived from the children stay those of a serial walk.
+	var pending sync.WaitGroup
 
 	for _, entry := range entries {
-		if entry.IsDir() {
-			joined := filepath.Join(path, entry.Name())
+		if !opts.ShowHidden && strings.HasPrefix(entry.Name(), ".") {
+			node.FilteredEntries++
+			continue
+		}
+		if opts.UseGitignore && gitignored(opts.ignores, filepath.Join(path, entry.Name()), entry.IsDir()) {
+			node.FilteredEntries++
+			continue
+		}
+		joined := filepath.Join(path, entry.Name())
+		isLink := entry.Type()&fs.ModeSymlink != 0
+		isDir, linkTarget := entry.IsDir(), ""
+		if isLink {
+			if info, err := os.Stat(joined); err == nil && info.IsDir() {
+				isDir = true
+				linkTarget, _ = os.Readlink(joined)
+			}
+		}
+		if isDir {
+			if isLink {
+				symlinks++
+			}
+			if isLink && !opts.FollowSymlinks {
+				subdirs = append(subdirs, &Directory{
+					Name:       entry.Name(),
+					Path:       joined,
+					Level:      level + 1,
+					Leaf:       true,
+					LinkTarget: linkTarget,
+					Signature:  signatureForLeaf(joined),
+				})
+				continue
+			}
+			childOpts := opts
+			if opts.FollowSymlinks {
+				real := filepath.Join(opts.ancestors[len(opts.ancestors)-1], entry.Name())
+				if isLink {
+					// EvalSymlinks keeps relative paths relative, while
+					// ancestors are absolute.
+					if resolved, err := filepath.EvalSymlinks(joined); err == nil {
+						if abs, err := filepath.Abs(resolved); err == nil {
+							real = abs
+						}
+					}
+					if slices.Contains(opts.ancestors, real) {
+						subdirs = append(subdirs, &Directory{
+							Name:         entry.Name(),
+							Path:         joined,
+							Level:        level + 1,
+							Leaf:         true,
+							LinkTarget:   linkTarget,
+							SymlinkCycle: true,
+							Signature:    signatureForLeaf(joined),
+						})
+						continue
+					}
+				}
+				childOpts.ancestors = append(opts.ancestors[:len(opts.ancestors):len(opts.ancestors)], real)
+			}
 			if opts.MaxLevel != 0 && level >= opts.MaxLevel {
 				subdir := &Directory{
 					Name:      entry.Name(),
 					Path:      joined,
 					Level:     level + 1,
+					Leaf:      true,
 					Signature: signatureForLeaf(joined),
 				}
 				subdirs = append(subdirs, subdir)
 				continue
 			}
+			if matchesAny(opts.StopAt, entry.Name()) {
+				subdirs = append(subdirs, &Directory{
+					Name:      entry.Name(),
+					Path:      joined,
+					Level:     level + 1,
+					Leaf:      true,
+					StoppedAt: true,
+					Signature: signatureForLeaf(joined),
+				})
+				continue
+			}
+			if opts.hasRootDevice && onOtherDevice(entry, opts.rootDevice) {
+				subdirs = append(subdirs, &Directory{
+					Name:            entry.Name(),
+					Path:            joined,
+					Level:           level + 1,
+					Leaf:            true,
+					OtherFilesystem: true,
+					Signature:       signatureForLeaf(joined),
+				})
+				continue
+			}
 
-			child := walkDir(joined, entry.Name(), level+1, opts)
+			var modTime time.Time
+			if info, err := entry.Info(); err == nil {
+				modTime = info.ModTime()
+			}
+			child := &Directory{}
 			subdirs = append(subdirs, child)
+			childName := entry.Name()
+			opts.walkAsync(&pending, func() {
+				*child = *walkDir(joined, childName, level+1, childOpts)
+				child.ModTime = modTime
+				child.LinkTarget = linkTarget
+			})
 			continue
 		}
 
 		filename := entry.Name()
-		ext := strings.ToLower(filepath.Ext(filename))
-		if ext == "" {
-			ext = "<noext>"
+		if !keepFile(filename, opts) {
+			node.FilteredEntries++
+			continue
+		}
+		special := specialKind(entry.Type())
+		if special != "" && opts.SkipSpecial {
+			node.FilteredEntries++
+			continue
+		}
+		matches := 0
+		if opts.ContentPattern != nil {
+			if special == "" {
+				limit := opts.ContentMaxSize
+				if limit <= 0 {
+					limit = defaultGrepMaxSize
+				}
+				matches = countMatchingLines(filepath.Join(path, filename), opts.ContentPattern, limit)
+			}
+			if matches == 0 {
+				node.FilteredEntries++
+				continue
+			}
+		}
+		if opts.ExpandArchives && special == "" && isArchive(filename) {
+			if archive, err := readArchive(filepath.Join(path, filename), filename, level+1, opts); err == nil {
+				subdirs = append(subdirs, archive)
+				continue
+			}
+		}
+
+		original := filepath.Ext(filename)
+		if original == "" {
+			original = "<noext>"
 		}
+		ext := strings.ToLower(original)
 		fileExtCounts[ext]++
+		if extSpellings[ext] == nil {
+			extSpellings[ext] = map[string]int{}
+		}
+		extSpellings[ext][original]++
+		if isReadme(filename) {
+			node.HasReadme = true
+		}
+		if special == "" && preferManifest(node.Package, filename, opts.PackageManifests) {
+			node.Package = readPackage(filepath.Join(path, filename), filename)
+		}
+
+		var size int64
+		var modTime time.Time
+		if special == "" {
+			if info, err := entry.Info(); err == nil {
+				size = info.Size()
+				modTime = info.ModTime()
+			}
+		}
+		immediateSize += size
+		if entry.Type()&fs.ModeSymlink != 0 {
+			symlinks++
+		}
+		extSizes[ext] += size
 
-		if len(files) < maxFiles {
-			files = append(files, FileEntry{Name: filename})
+		file := FileEntry{Name: filename, Size: size, ModTime: modTime, Special: special, Matches: matches}
+		if opts.HideSizeOver > 0 && size > opts.HideSizeOver {
+			hiddenFiles++
+		} else if sampler != nil {
+			sampler.offer(file)
+		} else if len(files) < maxFiles {
+			files = append(files, file)
 		} else {
 			hiddenFiles++
 		}
 	}
 
+	pending.Wait()
+	sortSubdirs(subdirs, opts.SortBy, opts.Reverse)
+
+	if sampler != nil {
+		files = sampler.files()
+		hiddenFiles += sampler.hidden()
+		node.Sampled = sampler.hidden() > 0
+	}
+
+	if opts.PreviewLines > 0 {
+		limit := opts.PreviewMaxSize
+		if limit <= 0 {
+			limit = defaultPreviewMaxSize
+		}
+		for idx := range files {
+			file := &files[idx]
+			if file.Special == "" && file.Size <= limit {
+				file.Preview, file.PreviewTruncated = readPreview(filepath.Join(path, file.Name), opts.PreviewLines, limit)
+			}
+		}
+	}
+
+	if opts.ShowOnlyExcluded {
+		kept := subdirs[:0]
+		for _, child := range subdirs {
+			if child.TotalFiles > 0 {
+				kept = append(kept, child)
+			} else {
+				node.FilteredEntries++
+			}
+		}
+		subdirs = kept
+	}
+
 	node.Subdirs = subdirs
 	node.Files = files
 	node.HiddenFiles = hiddenFiles
@@ -128,32 +575,108 @@ func walkDir(path, name string, level int, opts Options) *Directory {
 
 	totalDirs := len(subdirs)
 	totalFiles := node.ImmediateFileCount
+	totalSize := immediateSize
+	totalSymlinks := symlinks
+	totalErrors := 0
 	for _, child := range subdirs {
 		totalDirs += child.TotalDirs
 		totalFiles += child.TotalFiles
+		totalSize += child.TotalSize
+		totalSymlinks += child.TotalSymlinks
+		totalErrors += child.TotalErrors
+		if child.Err != nil {
+			totalErrors++
+		}
 	}
 	node.TotalDirs = totalDirs
 	node.TotalFiles = totalFiles
+	node.TotalSize = totalSize
+	node.TotalSymlinks = totalSymlinks
+	node.TotalErrors = totalErrors
 
-	node.Signature = signatureForDirectory(fileExtCounts, subdirs)
+	node.ExtCounts = fileExtCounts
+	node.ExtSpellings = extSpellings
+	node.ExtSizes = extSizes
+	if opts.SignatureFunc != nil {
+		node.Signature = opts.SignatureFunc(node)
+	} else if opts.SignatureDepth > 0 && level >= opts.SignatureDepth {
+		node.Signature = signatureForShallow(fileExtCounts, len(subdirs))
+	} else {
+		node.Signature = signatureForDirectory(fileExtCounts, subdirs)
+	}
+	if node.Package != nil {
+		// Keep differently named packages from collapsing into one another.
+		node.Signature += "|pkg:" + node.Package.Label()
+	}
 
 	return node
 }
 
-func signatureForDirectory(fileExtCounts map[string]int, subdirs []*Directory) string {
-	hasher := fnv.New64a()
-	hasher.Write([]byte("files:"))
+// walkAsync runs walk on a new goroutine when a worker is free and on the
+// calling one otherwise, so a parent never blocks on a full pool while
+// holding a worker itself.
+func (o Options) walkAsync(pending *sync.WaitGroup, walk func()) {
+	select {
+	case o.workers <- struct{}{}:
+		pending.Add(1)
+		go func() {
+			defer func() {
+				<-o.workers
+				pending.Done()
+			}()
+			walk()
+		}()
+	default:
+		walk()
+	}
+}
 
-	exts := make([]string, 0, len(fileExtCounts))
-	for ext := range fileExtCounts {
-		exts = append(exts, ext)
+func onOtherDevice(entry fs.DirEntry, rootDevice uint64) bool {
+	info, err := entry.Info()
+	if err != nil {
+		return false
 	}
-	sort.Strings(exts)
-	for _, ext := range exts {
-		hasher.Write([]byte(ext))
-		hasher.Write([]byte{0})
-		hasher.Write(intToBytes(fileExtCounts[ext]))
+	dev, ok := deviceID(info)
+	return ok && dev != rootDevice
+}
+
+// markUnexpanded records only the immediate counts of a directory that is too
+// large to expand.
+func markUnexpanded(node *Directory, entries []fs.DirEntry) {
+	node.Unexpanded = true
+	for _, entry := range entries {
+		if entry.IsDir() {
+			node.ImmediateDirCount++
+		} else {
+			node.ImmediateFileCount++
+		}
+	}
+	node.TotalDirs = node.ImmediateDirCount
+	node.TotalFiles = node.ImmediateFileCount
+	node.Signature = signatureForLeaf(node.Path)
+}
+
+// readDirUnsorted returns the entries of path in the order the filesystem
+// yields them. Unlike os.ReadDir it does not sort by name.
+func readDirUnsorted(path string) ([]fs.DirEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
 	}
+	defer f.Close()
+	return f.ReadDir(-1)
+}
+
+// DefaultSignature returns the built-in signature of a walked directory: a
+// hash of its per-extension file counts and its subdirectories' signatures.
+// Custom SignatureFunc hooks can use it as a fallback.
+func DefaultSignature(dir *Directory) string {
+	return signatureForDirectory(dir.ExtCounts, dir.Subdirs)
+}
+
+func signatureForDirectory(fileExtCounts map[string]int, subdirs []*Directory) string {
+	hasher := fnv.New64a()
+	writeExtCounts(hasher, fileExtCounts)
 
 	hasher.Write([]byte("dirs:"))
 	childSigs := make([]string, 0, len(subdirs))
@@ -169,6 +692,31 @@ func signatureForDirectory(fileExtCounts map[string]int, subdirs []*Directory) s
 	return fmt.Sprintf("d:%x", hasher.Sum64())
 }
 
+// signatureForShallow hashes a directory below Options.SignatureDepth: its
+// files as in signatureForDirectory, but only the count of its subdirectories.
+func signatureForShallow(fileExtCounts map[string]int, subdirCount int) string {
+	hasher := fnv.New64a()
+	writeExtCounts(hasher, fileExtCounts)
+	hasher.Write([]byte("dirs:"))
+	hasher.Write(intToBytes(subdirCount))
+	return fmt.Sprintf("s:%x", hasher.Sum64())
+}
+
+func writeExtCounts(hasher hash.Hash64, fileExtCounts map[string]int) {
+	hasher.Write([]byte("files:"))
+
+	exts := make([]string, 0, len(fileExtCounts))
+	for ext := range fileExtCounts {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	for _, ext := range exts {
+		hasher.Write([]byte(ext))
+		hasher.Write([]byte{0})
+		hasher.Write(intToBytes(fileExtCounts[ext]))
+	}
+}
+
 func signatureForLeaf(path string) string {
 	hasher := fnv.New64a()
 	hasher.Write([]byte("leaf:"))
@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeSyntheticTree creates a tree depth levels deep in which every
+// directory holds width subdirectories and files files of a few extensions.
+func writeSyntheticTree(tb testing.TB, depth, width, files int) string {
+	tb.Helper()
+	root := tb.TempDir()
+	var fill func(dir string, level int)
+	fill = func(dir string, level int) {
+		for idx := 0; idx < files; idx++ {
+			name := fmt.Sprintf("file%d%s", idx, []string{".go", ".md", ".txt"}[idx%3])
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); err != nil {
+				tb.Fatal(err)
+			}
+		}
+		if level == depth {
+			return
+		}
+		for idx := 0; idx < width; idx++ {
+			child := filepath.Join(dir, fmt.Sprintf("dir%d", idx))
+			if err := os.Mkdir(child, 0o755); err != nil {
+				tb.Fatal(err)
+			}
+			fill(child, level+1)
+		}
+	}
+	fill(root, 0)
+	return root
+}
+
+func TestConcurrentWalkMatchesSerial(t *testing.T) {
+	root := writeSyntheticTree(t, 4, 4, 5)
+	// Make some directories differ so not everything collapses.
+	for _, extra := range []string{"dir1/extra.go", "dir2/dir3/extra.md"} {
+		if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(extra)), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	serial, err := Walk(root, Options{MaxFiles: 3, Concurrency: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, concurrency := range []int{0, 2, 16} {
+		parallel, err := Walk(root, Options{MaxFiles: 3, Concurrency: concurrency})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(serial, parallel) {
+			t.Errorf("Concurrency %d: tree differs from a serial walk", concurrency)
+		}
+		if got, want := render(t, parallel, PrinterOptions{}), render(t, serial, PrinterOptions{}); got != want {
+			t.Errorf("Concurrency %d: output differs from a serial walk:\n%s\nwant:\n%s", concurrency, got, want)
+		}
+	}
+}
+
+func TestSignatureDepthCollapse(t *testing.T) {
+	// a and b differ only two levels down; c differs in its own files.
+	root := writeTree(t,
+		"a/x/one.go", "a/x/deep/two.go",
+		"b/x/one.go", "b/x/deep/two.md",
+		"c/extra.txt", "c/x/one.go",
+	)
+	groups := func(opts Options) [][]string {
+		t.Helper()
+		dir, err := Walk(root, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names [][]string
+		for _, group := range GroupIdentical(dir.Subdirs) {
+			var members []string
+			for _, member := range group.Members {
+				members = append(members, member.Name)
+			}
+			names = append(names, members)
+		}
+		return names
+	}
+
+	tests := []struct {
+		depth int
+		want  [][]string
+	}{
+		{0, [][]string{{"a"}, {"b"}, {"c"}}},
+		{1, [][]string{{"a", "b"}, {"c"}}},
+		{2, [][]string{{"a", "b"}, {"c"}}},
+		{3, [][]string{{"a"}, {"b"}, {"c"}}},
+	}
+	for _, tt := range tests {
+		if got := groups(Options{SignatureDepth: tt.depth}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SignatureDepth %d: groups = %v, want %v", tt.depth, got, tt.want)
+		}
+	}
+}
+
+func BenchmarkWalk(b *testing.B) {
+	// 6 levels of 5 subdirectories: 19531 directories, 156248 files.
+	root := writeSyntheticTree(b, 6, 5, 8)
+	for _, concurrency := range []int{1, 0} {
+		name := fmt.Sprintf("concurrency=%d", concurrency)
+		if concurrency == 0 {
+			name = "concurrency=GOMAXPROCS"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := Walk(root, Options{Concurrency: concurrency}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ExplainSignatures writes a human-readable comparison of the inputs that
+// make up the signatures of a and b: their per-extension file counts and
+// their subdirectories' signatures. Subdirectories that exist on both sides
+// under the same name but differ are explained recursively. It reports
+// whether the signatures are equal, that is whether GroupIdentical would
+// collapse the two directories if they were siblings.
+func ExplainSignatures(w io.Writer, a, b *Directory) bool {
+	fmt.Fprintf(w, "A: %s  %s\n", a.Path, a.Signature)
+	fmt.Fprintf(w, "B: %s  %s\n", b.Path, b.Signature)
+	same := a.Signature == b.Signature
+	if same {
+		fmt.Fprintln(w, "identical signatures: these directories collapse together")
+	} else {
+		fmt.Fprintln(w, "different signatures: these directories do not collapse together")
+	}
+	explainInputs(w, a, b, "")
+	return same
+}
+
+func explainInputs(w io.Writer, a, b *Directory, indent string) {
+	if a.Err != nil || b.Err != nil || a.Leaf || b.Leaf {
+		fmt.Fprintf(w, "%sunreadable or unexpanded directories get a signature unique to their path\n", indent)
+		return
+	}
+
+	fmt.Fprintf(w, "%sextension counts (A, B):\n", indent)
+	exts := make([]string, 0, len(a.ExtCounts)+len(b.ExtCounts))
+	for ext := range a.ExtCounts {
+		exts = append(exts, ext)
+	}
+	for ext := range b.ExtCounts {
+		if _, ok := a.ExtCounts[ext]; !ok {
+			exts = append(exts, ext)
+		}
+	}
+	sort.Strings(exts)
+	width := 0
+	for _, ext := range exts {
+		width = max(width, len(ext))
+	}
+	for _, ext := range exts {
+		marker := ""
+		if a.ExtCounts[ext] != b.ExtCounts[ext] {
+			marker = "  ✗"
+		}
+		fmt.Fprintf(w, "%s  %-*s  %4d  %4d%s\n", indent, width, ext, a.ExtCounts[ext], b.ExtCounts[ext], marker)
+	}
+	if len(exts) == 0 {
+		fmt.Fprintf(w, "%s  (no files)\n", indent)
+	}
+
+	onlyA, onlyB := unmatchedChildren(a.Subdirs, b.Subdirs)
+	fmt.Fprintf(w, "%schild signatures: %d in A, %d in B, %d unmatched\n", indent, len(a.Subdirs), len(b.Subdirs), len(onlyA)+len(onlyB))
+	for _, child := range onlyA {
+		fmt.Fprintf(w, "%s  only in A: %s/  %s\n", indent, child.Name, child.Signature)
+	}
+	for _, child := range onlyB {
+		fmt.Fprintf(w, "%s  only in B: %s/  %s\n", indent, child.Name, child.Signature)
+	}
+
+	for _, left := range onlyA {
+		for _, right := range onlyB {
+			if left.Name == right.Name {
+				fmt.Fprintf(w, "%s%s/ differs:\n", indent, left.Name)
+				explainInputs(w, left, right, indent+"    ")
+			}
+		}
+	}
+}
+
+// unmatchedChildren pairs up subdirectories by signature, like
+// GroupIdentical does, and returns those left over on each side.
+func unmatchedChildren(a, b []*Directory) (onlyA, onlyB []*Directory) {
+	remaining := make(map[string]int, len(b))
+	for _, child := range b {
+		remaining[child.Signature]++
+	}
+	for _, child := range a {
+		if remaining[child.Signature] > 0 {
+			remaining[child.Signature]--
+		} else {
+			onlyA = append(onlyA, child)
+		}
+	}
+	for _, child := range b {
+		if remaining[child.Signature] > 0 {
+			remaining[child.Signature]--
+			onlyB = append(onlyB, child)
+		}
+	}
+	return onlyA, onlyB
+}
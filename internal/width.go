@@ -0,0 +1,128 @@
+package internal
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// truncatingWriter cuts every line written through it to at most width
+// visible columns, ending shortened lines with "…". ANSI escape sequences do
+// not count toward the width and are kept intact. Each rune is assumed to
+// occupy one column.
+type truncatingWriter struct {
+	w       io.Writer
+	width   int
+	pending []byte
+}
+
+func newTruncatingWriter(w io.Writer, width int) *truncatingWriter {
+	return &truncatingWriter{w: w, width: width}
+}
+
+func (t *truncatingWriter) Write(p []byte) (int, error) {
+	t.pending = append(t.pending, p...)
+	for {
+		idx := bytes.IndexByte(t.pending, '\n')
+		if idx < 0 {
+			return len(p), nil
+		}
+		line := truncateLine(string(t.pending[:idx]), t.width)
+		t.pending = t.pending[idx+1:]
+		if _, err := io.WriteString(t.w, line+"\n"); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// Flush writes any trailing partial line.
+func (t *truncatingWriter) Flush() error {
+	if len(t.pending) == 0 {
+		return nil
+	}
+	line := truncateLine(string(t.pending), t.width)
+	t.pending = nil
+	_, err := io.WriteString(t.w, line)
+	return err
+}
+
+// visibleWidth returns the number of runes in s outside ANSI escape sequences.
+func visibleWidth(s string) int {
+	width := 0
+	for i := 0; i < len(s); {
+		if n := escapeLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		width++
+	}
+	return width
+}
+
+// truncateLine shortens s to width visible columns, replacing the overflow
+// with "…" and resetting colors and closing hyperlinks that were emitted.
+func truncateLine(s string, width int) string {
+	if width <= 0 || visibleWidth(s) <= width {
+		return s
+	}
+
+	var b strings.Builder
+	visible := 0
+	colored := false
+	linked := false
+	for i := 0; i < len(s) && visible < width-1; {
+		if n := escapeLen(s[i:]); n > 0 {
+			b.WriteString(s[i : i+n])
+			if s[i+1] == ']' {
+				linked = true
+			} else {
+				colored = true
+			}
+			i += n
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		b.WriteString(s[i : i+size])
+		i += size
+		visible++
+	}
+	b.WriteString("…")
+	if colored {
+		b.WriteString("\x1b[0m")
+	}
+	if linked {
+		b.WriteString("\x1b]8;;\x1b\\")
+	}
+	return b.String()
+}
+
+// escapeLen returns the length of the CSI (colors) or OSC (hyperlinks)
+// escape sequence at the start of s, or 0 if s does not start with one.
+func escapeLen(s string) int {
+	if len(s) < 2 || s[0] != 0x1b {
+		return 0
+	}
+	switch s[1] {
+	case '[':
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+		}
+	case ']':
+		for i := 2; i < len(s); i++ {
+			if s[i] == 0x07 {
+				return i + 1
+			}
+			if s[i] == 0x1b && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+	default:
+		return 0
+	}
+	return len(s)
+}